@@ -0,0 +1,71 @@
+package apperrors
+
+import "errors"
+
+// Jenis error domain, cek pakai errors.Is
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrValidation         = errors.New("validation error")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+)
+
+// Kode error yang dikirim ke client
+const (
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeRequired           = "required"
+	CodeInvalid            = "invalid"
+	CodeInvalidReference   = "invalid_reference"
+	CodeInsufficientStock  = "insufficient_stock"
+	CodePreconditionFailed = "precondition_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+)
+
+// Error error domain yang pesannya aman ditampilkan ke client
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Field   string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: CodeNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: ErrConflict, Code: CodeConflict, Message: message}
+}
+
+func Validation(code, field, message string) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Field: field, Message: message}
+}
+
+func InsufficientStock(field, message string) *Error {
+	return &Error{Kind: ErrInsufficientStock, Code: CodeInsufficientStock, Field: field, Message: message}
+}
+
+func PreconditionFailed(message string) *Error {
+	return &Error{Kind: ErrPreconditionFailed, Code: CodePreconditionFailed, Message: message}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: CodeUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: CodeForbidden, Message: message}
+}
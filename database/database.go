@@ -24,6 +24,12 @@ func InitDB(connectionString string) (*sql.DB, error) {
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(5)
 
+	// Jalankan migrasi schema
+	err = Migrate(db)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Println("Database connected successfully")
 	return db, nil
 }
@@ -0,0 +1,338 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+)
+
+// migrations dijalankan berurutan setiap kali aplikasi start,
+// jadi semua statement harus aman dijalankan berulang (IF NOT EXISTS)
+var migrations = []string{
+	// soft delete produk & kategori
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP NULL`,
+	`ALTER TABLE category ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE category ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP NULL`,
+
+	// hirarki kategori
+	`ALTER TABLE category ADD COLUMN IF NOT EXISTS parent_id INT NULL REFERENCES category(id)`,
+	`CREATE INDEX IF NOT EXISTS idx_category_parent_id ON category(parent_id)`,
+
+	// optimistic locking produk
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1`,
+
+	// histori & jadwal perubahan harga
+	`CREATE TABLE IF NOT EXISTS product_price_history (
+		id SERIAL PRIMARY KEY,
+		product_id INT NOT NULL REFERENCES product(id),
+		old_price INT NULL,
+		new_price INT NOT NULL,
+		source VARCHAR(20) NOT NULL DEFAULT 'manual',
+		schedule_id INT NULL,
+		changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_price_history_product ON product_price_history(product_id, changed_at)`,
+	`CREATE TABLE IF NOT EXISTS product_price_schedule (
+		id SERIAL PRIMARY KEY,
+		product_id INT NOT NULL REFERENCES product(id),
+		new_price INT NOT NULL,
+		effective_at TIMESTAMPTZ NOT NULL,
+		applied_at TIMESTAMPTZ NULL,
+		cancelled_at TIMESTAMPTZ NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_price_schedule_due ON product_price_schedule(effective_at) WHERE applied_at IS NULL AND cancelled_at IS NULL`,
+
+	// daftar harga (retail, grosir, member) dan harga bertingkat per qty
+	`CREATE TABLE IF NOT EXISTS price_list (
+		id SERIAL PRIMARY KEY,
+		code VARCHAR(30) NOT NULL UNIQUE,
+		name VARCHAR(100) NOT NULL
+	)`,
+	`INSERT INTO price_list (code, name) VALUES ('retail', 'Retail'), ('wholesale', 'Grosir'), ('member', 'Member')
+		ON CONFLICT (code) DO NOTHING`,
+	`CREATE TABLE IF NOT EXISTS product_price (
+		id SERIAL PRIMARY KEY,
+		product_id INT NOT NULL REFERENCES product(id),
+		price_list_id INT NOT NULL REFERENCES price_list(id),
+		min_qty INT NOT NULL DEFAULT 1 CHECK (min_qty >= 1),
+		price INT NOT NULL CHECK (price >= 0),
+		UNIQUE (product_id, price_list_id, min_qty)
+	)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS price_list_id INT NULL REFERENCES price_list(id)`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_price INT NULL`,
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS price_list_id INT NULL REFERENCES price_list(id)`,
+
+	// customer & poin loyalty
+	`CREATE TABLE IF NOT EXISTS customer (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		phone VARCHAR(30) NULL UNIQUE,
+		email VARCHAR(100) NULL UNIQUE,
+		price_list_id INT NULL REFERENCES price_list(id),
+		points_balance INT NOT NULL DEFAULT 0 CHECK (points_balance >= 0),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id INT NULL REFERENCES customer(id)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_redeemed INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_earned INT NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS amount_due INT NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_transactions_customer ON transactions(customer_id)`,
+	`CREATE TABLE IF NOT EXISTS loyalty_ledger (
+		id SERIAL PRIMARY KEY,
+		customer_id INT NOT NULL REFERENCES customer(id),
+		transaction_id INT NULL REFERENCES transactions(id),
+		points INT NOT NULL,
+		reason VARCHAR(20) NOT NULL,
+		balance_after INT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_customer ON loyalty_ledger(customer_id, created_at)`,
+
+	// order draft / parkir sebelum checkout
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS reserved_stock INT NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS orders (
+		id SERIAL PRIMARY KEY,
+		status VARCHAR(20) NOT NULL DEFAULT 'draft',
+		customer_id INT NULL REFERENCES customer(id),
+		price_list VARCHAR(30) NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT '',
+		stock_reserved BOOLEAN NOT NULL DEFAULT FALSE,
+		transaction_id INT NULL REFERENCES transactions(id),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status)`,
+	`CREATE TABLE IF NOT EXISTS order_items (
+		id SERIAL PRIMARY KEY,
+		order_id INT NOT NULL REFERENCES orders(id),
+		product_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL CHECK (quantity > 0)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items(order_id)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS order_id INT NULL REFERENCES orders(id)`,
+
+	// meja dine-in & tiket dapur per ronde
+	`CREATE TABLE IF NOT EXISTS dining_tables (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(30) NOT NULL UNIQUE,
+		seats INT NOT NULL DEFAULT 0,
+		archived BOOLEAN NOT NULL DEFAULT FALSE,
+		archived_at TIMESTAMPTZ NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`ALTER TABLE orders ADD COLUMN IF NOT EXISTS table_id INT NULL REFERENCES dining_tables(id)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_open_table ON orders(table_id)
+		WHERE table_id IS NOT NULL AND status IN ('draft', 'parked')`,
+	`CREATE TABLE IF NOT EXISTS kitchen_tickets (
+		id SERIAL PRIMARY KEY,
+		order_id INT NOT NULL REFERENCES orders(id),
+		round INT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		UNIQUE (order_id, round)
+	)`,
+	`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS ticket_id INT NULL REFERENCES kitchen_tickets(id)`,
+
+	// pembayaran & split bill
+	`CREATE TABLE IF NOT EXISTS payments (
+		id SERIAL PRIMARY KEY,
+		transaction_id INT NOT NULL REFERENCES transactions(id),
+		order_id INT NULL REFERENCES orders(id),
+		part INT NOT NULL DEFAULT 1,
+		parts INT NOT NULL DEFAULT 1,
+		amount INT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		UNIQUE (transaction_id, part)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_payments_order ON payments(order_id)`,
+
+	// multi outlet: stok per outlet, product.stock & reserved_stock jadi total semua outlet
+	`CREATE TABLE IF NOT EXISTS outlet (
+		id SERIAL PRIMARY KEY,
+		code VARCHAR(30) NOT NULL UNIQUE,
+		name VARCHAR(100) NOT NULL,
+		address TEXT NOT NULL DEFAULT '',
+		archived BOOLEAN NOT NULL DEFAULT FALSE,
+		archived_at TIMESTAMPTZ NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`INSERT INTO outlet (code, name) VALUES ('main', 'Outlet Utama') ON CONFLICT (code) DO NOTHING`,
+	`CREATE TABLE IF NOT EXISTS outlet_stock (
+		outlet_id INT NOT NULL REFERENCES outlet(id),
+		product_id INT NOT NULL REFERENCES product(id),
+		stock INT NOT NULL DEFAULT 0,
+		reserved_stock INT NOT NULL DEFAULT 0,
+		PRIMARY KEY (outlet_id, product_id)
+	)`,
+	// stok lama dipindah ke outlet utama, hanya sekali saat outlet_stock masih kosong
+	`INSERT INTO outlet_stock (outlet_id, product_id, stock, reserved_stock)
+		SELECT o.id, p.id, p.stock, p.reserved_stock FROM product p, outlet o
+		WHERE o.code = 'main' AND NOT EXISTS (SELECT 1 FROM outlet_stock)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS outlet_id INT NULL REFERENCES outlet(id)`,
+	`UPDATE transactions SET outlet_id = (SELECT id FROM outlet WHERE code = 'main') WHERE outlet_id IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_transactions_outlet ON transactions(outlet_id, created_at)`,
+	`ALTER TABLE orders ADD COLUMN IF NOT EXISTS outlet_id INT NULL REFERENCES outlet(id)`,
+	`UPDATE orders SET outlet_id = (SELECT id FROM outlet WHERE code = 'main') WHERE outlet_id IS NULL`,
+	`CREATE TABLE IF NOT EXISTS users (
+		id SERIAL PRIMARY KEY,
+		username VARCHAR(50) NOT NULL UNIQUE,
+		name VARCHAR(100) NOT NULL DEFAULT '',
+		role VARCHAR(20) NOT NULL DEFAULT 'cashier',
+		outlet_id INT NULL REFERENCES outlet(id),
+		token_hash CHAR(64) NOT NULL UNIQUE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+
+	// transfer stok antar outlet
+	`CREATE TABLE IF NOT EXISTS stock_transfer (
+		id SERIAL PRIMARY KEY,
+		from_outlet_id INT NOT NULL REFERENCES outlet(id),
+		to_outlet_id INT NOT NULL REFERENCES outlet(id),
+		status VARCHAR(20) NOT NULL DEFAULT 'draft',
+		note TEXT NOT NULL DEFAULT '',
+		receive_note TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		shipped_at TIMESTAMPTZ NULL,
+		received_at TIMESTAMPTZ NULL,
+		CHECK (from_outlet_id <> to_outlet_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_stock_transfer_status ON stock_transfer(status)`,
+	`CREATE TABLE IF NOT EXISTS stock_transfer_items (
+		id SERIAL PRIMARY KEY,
+		transfer_id INT NOT NULL REFERENCES stock_transfer(id),
+		product_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL CHECK (quantity > 0),
+		received_quantity INT NULL CHECK (received_quantity >= 0),
+		UNIQUE (transfer_id, product_id)
+	)`,
+
+	// laporan pakai zona waktu toko: created_at transaksi lama (TIMESTAMP tanpa zona, diisi waktu UTC)
+	// diubah ke TIMESTAMPTZ sekali saja, filter laporan pakai rentang waktu supaya index terpakai
+	`DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'transactions' AND column_name = 'created_at'
+			  AND data_type = 'timestamp without time zone'
+		) THEN
+			ALTER TABLE transactions ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+		END IF;
+	END $$`,
+	`CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions(created_at)`,
+
+	// metode pembayaran & tutup hari (Z report)
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS payment_method VARCHAR(20) NOT NULL DEFAULT 'cash'`,
+	`CREATE TABLE IF NOT EXISTS z_reports (
+		id SERIAL PRIMARY KEY,
+		outlet_id INT NOT NULL REFERENCES outlet(id),
+		sequence INT NOT NULL,
+		business_date DATE NOT NULL,
+		period_start TIMESTAMPTZ NOT NULL,
+		period_end TIMESTAMPTZ NOT NULL,
+		transaction_count INT NOT NULL,
+		items_sold INT NOT NULL,
+		gross_sales INT NOT NULL,
+		points_redeemed INT NOT NULL,
+		net_sales INT NOT NULL,
+		payments JSONB NOT NULL,
+		first_transaction_id INT NULL,
+		last_transaction_id INT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		closed_by INT NULL REFERENCES users(id),
+		closed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		UNIQUE (outlet_id, sequence),
+		UNIQUE (outlet_id, business_date)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_z_reports_period ON z_reports(outlet_id, period_start, period_end)`,
+	// Z report tidak boleh diubah atau dihapus, termasuk lewat SQL langsung
+	`CREATE OR REPLACE FUNCTION z_reports_immutable() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'z_reports tidak bisa diubah atau dihapus' USING ERRCODE = 'object_not_in_prerequisite_state';
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS z_reports_immutable ON z_reports`,
+	`CREATE TRIGGER z_reports_immutable BEFORE UPDATE OR DELETE ON z_reports
+		FOR EACH ROW EXECUTE FUNCTION z_reports_immutable()`,
+	// transaksi (dan detailnya) di hari bisnis yang sudah ditutup juga terkunci.
+	// Kode error object_not_in_prerequisite_state diterjemahkan jadi 409 oleh translateDBError
+	`CREATE OR REPLACE FUNCTION closed_day_guard() RETURNS trigger AS $$
+	DECLARE
+		t_id INT;
+	BEGIN
+		IF TG_TABLE_NAME = 'transactions' THEN
+			t_id := OLD.id;
+		ELSE
+			t_id := OLD.transaction_id;
+		END IF;
+		IF EXISTS (
+			SELECT 1 FROM transactions t
+			JOIN z_reports z ON z.outlet_id = t.outlet_id AND t.created_at >= z.period_start AND t.created_at < z.period_end
+			WHERE t.id = t_id
+		) THEN
+			RAISE EXCEPTION 'transaksi % sudah masuk Z report dan tidak bisa diubah', t_id
+				USING ERRCODE = 'object_not_in_prerequisite_state';
+		END IF;
+		IF TG_OP = 'DELETE' THEN
+			RETURN OLD;
+		END IF;
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS transactions_closed_day ON transactions`,
+	`CREATE TRIGGER transactions_closed_day BEFORE UPDATE OR DELETE ON transactions
+		FOR EACH ROW EXECUTE FUNCTION closed_day_guard()`,
+	`DROP TRIGGER IF EXISTS transaction_details_closed_day ON transaction_details`,
+	`CREATE TRIGGER transaction_details_closed_day BEFORE UPDATE OR DELETE ON transaction_details
+		FOR EACH ROW EXECUTE FUNCTION closed_day_guard()`,
+
+	// harga pokok untuk nilai persediaan
+	`ALTER TABLE product ADD COLUMN IF NOT EXISTS cost INT NULL CHECK (cost >= 0)`,
+
+	// ringkasan penjualan harian untuk laporan, business_date menurut zona waktu toko.
+	// Diisi saat checkout, data lama dan perbaikan lewat perintah backfill-summary
+	`CREATE TABLE IF NOT EXISTS daily_sales_totals (
+		business_date DATE NOT NULL,
+		outlet_id INT NOT NULL REFERENCES outlet(id),
+		transaction_count INT NOT NULL DEFAULT 0,
+		revenue BIGINT NOT NULL DEFAULT 0,
+		items_sold BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (business_date, outlet_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS daily_sales_summary (
+		business_date DATE NOT NULL,
+		outlet_id INT NOT NULL REFERENCES outlet(id),
+		product_id INT NOT NULL REFERENCES product(id),
+		qty BIGINT NOT NULL DEFAULT 0,
+		revenue BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (business_date, outlet_id, product_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_daily_sales_summary_product ON daily_sales_summary(product_id, business_date)`,
+
+	// kolom refund & pajak Z report sempat dibuat dengan isi 0 semua, dibuang sampai fitur refund dan pajak ada
+	`ALTER TABLE z_reports DROP COLUMN IF EXISTS refund_count, DROP COLUMN IF EXISTS refund_amount, DROP COLUMN IF EXISTS tax_amount`,
+
+	// kekurangan terima transfer: dikembalikan ke outlet asal atau di-write-off dengan catatan
+	`ALTER TABLE stock_transfer ADD COLUMN IF NOT EXISTS shortage_action VARCHAR(20) NOT NULL DEFAULT ''`,
+	`CREATE TABLE IF NOT EXISTS stock_write_off (
+		id SERIAL PRIMARY KEY,
+		outlet_id INT NOT NULL REFERENCES outlet(id),
+		product_id INT NOT NULL REFERENCES product(id),
+		quantity INT NOT NULL CHECK (quantity > 0),
+		reason VARCHAR(30) NOT NULL,
+		transfer_id INT NULL REFERENCES stock_transfer(id),
+		note TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_stock_write_off_transfer ON stock_write_off(transfer_id)`,
+}
+
+func Migrate(db *sql.DB) error {
+	for _, m := range migrations {
+		if _, err := db.Exec(m); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Database migrated successfully")
+	return nil
+}
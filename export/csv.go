@@ -0,0 +1,57 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// WriteCSV judul & keterangan di baris awal, setiap section dipisah satu baris kosong
+// dan diawali baris judul section
+func WriteCSV(w io.Writer, doc *Document) error {
+	cw := csv.NewWriter(w)
+
+	if doc.Title != "" {
+		cw.Write([]string{csvText(doc.Title)})
+	}
+	for _, line := range doc.Subtitle {
+		cw.Write([]string{csvText(line)})
+	}
+
+	for i, section := range doc.Sections {
+		if i > 0 || doc.Title != "" || len(doc.Subtitle) > 0 {
+			cw.Write([]string{})
+		}
+		if section.Title != "" {
+			cw.Write([]string{csvText(section.Title)})
+		}
+		headers := make([]string, len(section.Headers))
+		for j, h := range section.Headers {
+			headers[j] = csvText(h)
+		}
+		cw.Write(headers)
+		for _, row := range section.Rows {
+			record := make([]string, len(row))
+			for j, v := range row {
+				if isNumber(v) {
+					record[j] = cellText(v)
+					continue
+				}
+				record[j] = csvText(cellText(v))
+			}
+			cw.Write(record)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvText teks yang diawali karakter pemicu formula spreadsheet (= + - @ tab CR) diberi awalan ',
+// supaya nama produk seperti =HYPERLINK(...) tidak jadi formula aktif saat CSV dibuka
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *Document
+		want string
+	}{
+		{
+			name: "quoting",
+			doc: &Document{Sections: []Section{{
+				Headers: []string{"Nama", "Qty"},
+				Rows: [][]interface{}{
+					{"Kopi, susu", 2},
+					{`Teh "tarik"`, 1},
+					{"Roti\nbakar", 3},
+				},
+			}}},
+			want: "Nama,Qty\n\"Kopi, susu\",2\n\"Teh \"\"tarik\"\"\",1\n\"Roti\nbakar\",3\n",
+		},
+		{
+			name: "judul, keterangan dan section",
+			doc: &Document{
+				Title:    "Laporan",
+				Subtitle: []string{"Periode: 2026-01-01"},
+				Sections: []Section{
+					{Title: "A", Headers: []string{"X"}, Rows: [][]interface{}{{1.5}}},
+					{Title: "B", Headers: []string{"Y", "Z"}, Rows: [][]interface{}{{nil, ""}}},
+				},
+			},
+			want: "Laporan\nPeriode: 2026-01-01\n\nA\nX\n1.5\n\nB\nY,Z\n,\n",
+		},
+		{
+			name: "formula injection",
+			doc: &Document{
+				Title: "=cmd",
+				Sections: []Section{{
+					Headers: []string{"+h"},
+					Rows: [][]interface{}{
+						{`=HYPERLINK("http://x","klik")`},
+						{"+1"},
+						{"-1"},
+						{"@SUM(A1)"},
+						{"\tTab"},
+						{"\rCR"},
+						{"Aman = tidak"},
+						{-5},
+						{-2.5},
+					},
+				}},
+			},
+			want: "'=cmd\n\n'+h\n\"'=HYPERLINK(\"\"http://x\"\",\"\"klik\"\")\"\n'+1\n'-1\n'@SUM(A1)\n'\tTab\n\"'\rCR\"\nAman = tidak\n-5\n-2.5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteCSV(&buf, tt.doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteCSV() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Package export menulis laporan tabular ke CSV, XLSX dan PDF tanpa dependency tambahan
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format export yang didukung
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// ContentTypes content type per format, dipakai juga untuk negosiasi header Accept
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatPDF:  "application/pdf",
+}
+
+// Document satu laporan, terdiri dari beberapa tabel (Section)
+type Document struct {
+	Title    string
+	Subtitle []string // baris keterangan di bawah judul, misal periode dan outlet
+	Sections []Section
+}
+
+// Section satu tabel. Isi Rows berupa string, int atau float64;
+// angka tetap angka di XLSX dan rata kanan di PDF
+type Section struct {
+	Title   string
+	Headers []string
+	Rows    [][]interface{}
+}
+
+// Write tulis doc ke w sesuai format
+func Write(w io.Writer, format string, doc *Document) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, doc)
+	case FormatXLSX:
+		return WriteXLSX(w, doc)
+	case FormatPDF:
+		return WritePDF(w, doc)
+	}
+	return fmt.Errorf("export: format %q tidak didukung", format)
+}
+
+// cellText nilai cell apa adanya, tanpa pemisah ribuan (untuk CSV & XLSX)
+func cellText(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int:
+		return strconv.Itoa(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// isNumber cell berupa angka
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, float64:
+		return true
+	}
+	return false
+}
+
+// displayText nilai cell untuk dibaca manusia, angka pakai pemisah ribuan titik dan desimal koma
+func displayText(v interface{}) string {
+	switch x := v.(type) {
+	case int:
+		return groupThousands(strconv.Itoa(x))
+	case float64:
+		s := strconv.FormatFloat(x, 'f', 2, 64)
+		whole, frac, _ := strings.Cut(s, ".")
+		return groupThousands(whole) + "," + frac
+	}
+	return cellText(v)
+}
+
+func groupThousands(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String()
+}
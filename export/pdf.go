@@ -0,0 +1,285 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ukuran halaman A4 portrait dalam point
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 40.0
+	pdfCellPad    = 4.0
+	pdfTableSize  = 9.0
+	pdfRowHeight  = 13.0
+)
+
+// font standar PDF, tidak perlu di-embed
+const (
+	fontRegular = "F1" // Helvetica
+	fontBold    = "F2" // Helvetica-Bold
+)
+
+// helveticaWidths lebar karakter ASCII 32..126 Helvetica (per 1000 unit font)
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth perkiraan lebar teks dalam point, Helvetica-Bold dianggap 10% lebih lebar
+func textWidth(s, font string, size float64) float64 {
+	units := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			units += helveticaWidths[r-32]
+		} else {
+			units += 556
+		}
+	}
+	w := float64(units) * size / 1000
+	if font == fontBold {
+		w *= 1.1
+	}
+	return w
+}
+
+// fitText potong teks dengan "..." supaya muat di lebar width
+func fitText(s, font string, size, width float64) string {
+	// toleransi kecil untuk pembulatan float, lebar kolom dihitung dari teks yang sama
+	if textWidth(s, font, size) <= width+0.01 {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && textWidth(string(r)+"...", font, size) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "..."
+}
+
+// pdfString literal string PDF dalam encoding WinAnsi, karakter di luar Latin-1 jadi "?"
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r <= 126:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfLayout menyusun isi halaman dari atas ke bawah, pindah halaman otomatis
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, &bytes.Buffer{})
+	l.y = pdfPageHeight - pdfMargin
+}
+
+// ensure pindah halaman kalau sisa ruang kurang dari height, true kalau pindah
+func (l *pdfLayout) ensure(height float64) bool {
+	if len(l.pages) == 0 || l.y-height < pdfMargin+pdfRowHeight {
+		l.newPage()
+		return true
+	}
+	return false
+}
+
+func (l *pdfLayout) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(l.pages[len(l.pages)-1], "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+func (l *pdfLayout) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(l.pages[len(l.pages)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// WritePDF dokumen A4 dengan judul, keterangan dan tabel per section. Header tabel diulang
+// di setiap halaman baru, angka rata kanan dengan pemisah ribuan, nomor halaman di kaki halaman
+func WritePDF(w io.Writer, doc *Document) error {
+	l := &pdfLayout{}
+	l.newPage()
+	contentWidth := pdfPageWidth - 2*pdfMargin
+
+	if doc.Title != "" {
+		l.y -= 14
+		l.text(pdfMargin, l.y, fontBold, 14, fitText(doc.Title, fontBold, 14, contentWidth))
+		l.y -= 6
+	}
+	for _, line := range doc.Subtitle {
+		l.y -= 12
+		l.text(pdfMargin, l.y, fontRegular, 9, fitText(line, fontRegular, 9, contentWidth))
+	}
+
+	for _, section := range doc.Sections {
+		widths := columnWidths(section, contentWidth)
+		numeric := numericColumns(section)
+
+		l.y -= 10
+		l.ensure(3 * pdfRowHeight)
+		if section.Title != "" {
+			l.y -= 14
+			l.text(pdfMargin, l.y, fontBold, 11, fitText(section.Title, fontBold, 11, contentWidth))
+			l.y -= 4
+		}
+		writeHeader := func() {
+			if len(section.Headers) == 0 {
+				return
+			}
+			l.y -= pdfRowHeight
+			cells := make([]interface{}, len(section.Headers))
+			for i, h := range section.Headers {
+				cells[i] = h
+			}
+			l.row(cells, widths, numeric, fontBold)
+			l.line(pdfMargin, l.y-3, pdfMargin+sum(widths), l.y-3)
+		}
+		writeHeader()
+
+		if len(section.Rows) == 0 {
+			l.y -= pdfRowHeight
+			l.text(pdfMargin+pdfCellPad, l.y, fontRegular, pdfTableSize, "Tidak ada data")
+		}
+		for _, row := range section.Rows {
+			if l.ensure(pdfRowHeight) {
+				writeHeader()
+			}
+			l.y -= pdfRowHeight
+			l.row(row, widths, numeric, fontRegular)
+		}
+	}
+
+	// nomor halaman baru bisa ditulis setelah jumlah halaman diketahui
+	for i := range l.pages {
+		label := fmt.Sprintf("Halaman %d / %d", i+1, len(l.pages))
+		fmt.Fprintf(l.pages[i], "BT /%s 8.0 Tf %.2f %.2f Td %s Tj ET\n", fontRegular,
+			pdfPageWidth-pdfMargin-textWidth(label, fontRegular, 8), pdfMargin/2, pdfString(label))
+	}
+
+	return writePDFObjects(w, l.pages)
+}
+
+// row satu baris tabel di posisi l.y, angka dan header kolom angka rata kanan
+func (l *pdfLayout) row(cells []interface{}, widths []float64, numeric []bool, font string) {
+	x := pdfMargin
+	for i, v := range cells {
+		if i >= len(widths) {
+			break
+		}
+		inner := widths[i] - 2*pdfCellPad
+		s := fitText(displayText(v), font, pdfTableSize, inner)
+		if isNumber(v) || numeric[i] {
+			l.text(x+widths[i]-pdfCellPad-textWidth(s, font, pdfTableSize), l.y, font, pdfTableSize, s)
+		} else {
+			l.text(x+pdfCellPad, l.y, font, pdfTableSize, s)
+		}
+		x += widths[i]
+	}
+}
+
+// columnWidths lebar kolom mengikuti isi terpanjang, diperkecil proporsional kalau melebihi lebar halaman
+func columnWidths(section Section, maxWidth float64) []float64 {
+	cols := len(section.Headers)
+	for _, row := range section.Rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	widths := make([]float64, cols)
+	for i, h := range section.Headers {
+		widths[i] = textWidth(h, fontBold, pdfTableSize) + 2*pdfCellPad
+	}
+	for _, row := range section.Rows {
+		for i, v := range row {
+			if w := textWidth(displayText(v), fontRegular, pdfTableSize) + 2*pdfCellPad; w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	if total := sum(widths); total > maxWidth {
+		for i := range widths {
+			widths[i] *= maxWidth / total
+		}
+	}
+	return widths
+}
+
+// numericColumns kolom yang berisi angka, dilihat dari baris pertama yang terisi
+func numericColumns(section Section) []bool {
+	numeric := make([]bool, len(section.Headers))
+	for _, row := range section.Rows {
+		if len(row) > len(numeric) {
+			numeric = append(numeric, make([]bool, len(row)-len(numeric))...)
+		}
+	}
+	for i := range numeric {
+		for _, row := range section.Rows {
+			if i < len(row) && row[i] != nil && row[i] != "" {
+				numeric[i] = isNumber(row[i])
+				break
+			}
+		}
+	}
+	return numeric
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// writePDFObjects susun objek PDF: 1 catalog, 2 pages, 3-4 font, lalu pasangan page & content per halaman
+func writePDFObjects(w io.Writer, pages []*bytes.Buffer) error {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, fontRegular, fontBold, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,178 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Kopi", "(Kopi)"},
+		{"(promo)", `(\(promo\))`},
+		{`C:\data`, `(C:\\data)`},
+		{`a)\(b`, `(a\)\\\(b)`},
+		{"Caf\u00e9", `(Caf\351)`},
+		{"Rp\u00a0100", `(Rp\240100)`},
+		{"\u4e2d\u6587", "(??)"},
+		{"tab\tbaris", "(tab?baris)"},
+		{"", "()"},
+	}
+	for _, tt := range tests {
+		if got := pdfString(tt.in); got != tt.want {
+			t.Errorf("pdfString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		width float64
+		want  string
+	}{
+		{"muat", "Kopi", 100, "Kopi"},
+		{"pas lebar sendiri", "Metode", textWidth("Metode", fontRegular, pdfTableSize), "Metode"},
+		{"dipotong", "Kopi susu gula aren", textWidth("Kopi su...", fontRegular, pdfTableSize), "Kopi su..."},
+		{"terlalu sempit", "Kopi", 1, "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitText(tt.in, fontRegular, pdfTableSize, tt.width); got != tt.want {
+				t.Errorf("fitText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var (
+	pdfStartXref = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	pdfStream    = regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`)
+)
+
+// checkPDFStructure cek offset xref menunjuk ke objek yang benar dan /Length sama dengan panjang stream
+func checkPDFStructure(t *testing.T, out []byte) (objects int) {
+	t.Helper()
+	s := string(out)
+	if !strings.HasPrefix(s, "%PDF-1.4\n") {
+		t.Fatal("header PDF tidak ada")
+	}
+
+	m := pdfStartXref.FindStringSubmatch(s)
+	if m == nil {
+		t.Fatal("startxref tidak ada")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(s[xref:], "xref\n") {
+		t.Fatalf("startxref %d tidak menunjuk ke tabel xref", xref)
+	}
+
+	lines := strings.Split(s[xref:], "\n")
+	var first, count int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &count); err != nil || first != 0 {
+		t.Fatalf("subsection xref tidak valid: %q", lines[1])
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Errorf("entri xref 0 = %q", lines[2])
+	}
+	for i := 1; i < count; i++ {
+		entry := lines[2+i]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("entri xref %d tidak valid: %q", i, entry)
+		}
+		off, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj\n", i); !strings.HasPrefix(s[off:], want) {
+			t.Errorf("offset objek %d (%d) menunjuk ke %q", i, off, s[off:off+10])
+		}
+	}
+	if !strings.Contains(s, fmt.Sprintf("/Size %d ", count)) {
+		t.Errorf("trailer /Size tidak sama dengan %d", count)
+	}
+
+	for _, idx := range pdfStream.FindAllStringSubmatchIndex(s, -1) {
+		length, _ := strconv.Atoi(s[idx[2]:idx[3]])
+		if !strings.HasPrefix(s[idx[1]+length:], "endstream") {
+			t.Errorf("/Length %d tidak sesuai panjang stream", length)
+		}
+	}
+	return count - 1
+}
+
+func TestWritePDF(t *testing.T) {
+	many := make([][]interface{}, 150)
+	for i := range many {
+		many[i] = []interface{}{fmt.Sprintf("Produk %d", i+1), i * 1000}
+	}
+
+	tests := []struct {
+		name     string
+		doc      *Document
+		pages    int
+		contains []string
+	}{
+		{
+			name:     "kosong",
+			doc:      &Document{Title: "Laporan"},
+			pages:    1,
+			contains: []string{"(Laporan) Tj", "(Halaman 1 / 1) Tj"},
+		},
+		{
+			name: "escape dan angka",
+			doc: &Document{
+				Title: `Laporan (harian) \ outlet`,
+				Sections: []Section{{
+					Headers: []string{"Nama", "Omzet"},
+					Rows:    [][]interface{}{{"Kopi (besar)", 1250000}, {"Teh", 2.5}},
+				}},
+			},
+			pages:    1,
+			contains: []string{`(Laporan \(harian\) \\ outlet) Tj`, `(Kopi \(besar\)) Tj`, "(1.250.000) Tj", "(2,50) Tj"},
+		},
+		{
+			name:     "section tanpa baris",
+			doc:      &Document{Sections: []Section{{Headers: []string{"A"}}}},
+			pages:    1,
+			contains: []string{"(Tidak ada data) Tj"},
+		},
+		{
+			name:     "banyak halaman, header diulang",
+			doc:      &Document{Title: "Panjang", Sections: []Section{{Headers: []string{"Nama", "Nilai"}, Rows: many}}},
+			pages:    3,
+			contains: []string{"(Produk 150) Tj", "(Halaman 3 / 3) Tj"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WritePDF(&buf, tt.doc); err != nil {
+				t.Fatal(err)
+			}
+			objects := checkPDFStructure(t, buf.Bytes())
+			if want := 4 + 2*tt.pages; objects != want {
+				t.Errorf("jumlah objek = %d, want %d", objects, want)
+			}
+			out := buf.String()
+			if !strings.Contains(out, fmt.Sprintf("/Count %d ", tt.pages)) {
+				t.Errorf("/Count bukan %d", tt.pages)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(out, want) {
+					t.Errorf("PDF tidak berisi %s", want)
+				}
+			}
+			if tt.pages > 1 {
+				if got := strings.Count(out, "(Nilai) Tj"); got != tt.pages {
+					t.Errorf("header tabel muncul %d kali, want %d", got, tt.pages)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteXLSX workbook Office Open XML minimal: satu sheet per section, judul dokumen di atas
+// setiap sheet, header tebal. String ditulis inline supaya tidak perlu sharedStrings
+func WriteXLSX(w io.Writer, doc *Document) error {
+	sections := doc.Sections
+	if len(sections) == 0 {
+		sections = []Section{{Title: doc.Title}}
+	}
+	names := sheetNames(sections)
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypesXML(len(sections))},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", workbookXML(names)},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML(len(sections))},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, f := range files {
+		if err := writeZipFile(zw, f.name, f.content); err != nil {
+			return err
+		}
+	}
+	for i, section := range sections {
+		if err := writeZipFile(zw, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheetXML(doc, section)); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name, content string) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+// sheetNames nama sheet dari judul section: maksimal 31 karakter, tanpa karakter terlarang, unik
+func sheetNames(sections []Section) []string {
+	used := make(map[string]bool)
+	names := make([]string, len(sections))
+	for i, section := range sections {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return -1
+			}
+			return r
+		}, section.Title)
+		if name == "" {
+			name = fmt.Sprintf("Sheet%d", i+1)
+		}
+		if len([]rune(name)) > 31 {
+			name = string([]rune(name)[:31])
+		}
+		base := name
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			r := []rune(base)
+			if len(r)+len(suffix) > 31 {
+				r = r[:31-len(suffix)]
+			}
+			name = string(r) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// style index di styles.xml
+const (
+	styleNormal = 0
+	styleBold   = 1
+)
+
+func sheetXML(doc *Document, section Section) string {
+	var rows [][]interface{}
+	var styles []int
+	if doc.Title != "" {
+		rows = append(rows, []interface{}{doc.Title})
+		styles = append(styles, styleBold)
+	}
+	for _, line := range doc.Subtitle {
+		rows = append(rows, []interface{}{line})
+		styles = append(styles, styleNormal)
+	}
+	if len(rows) > 0 {
+		rows = append(rows, nil)
+		styles = append(styles, styleNormal)
+	}
+	if len(section.Headers) > 0 {
+		header := make([]interface{}, len(section.Headers))
+		for i, h := range section.Headers {
+			header[i] = h
+		}
+		rows = append(rows, header)
+		styles = append(styles, styleBold)
+	}
+	for _, row := range section.Rows {
+		rows = append(rows, row)
+		styles = append(styles, styleNormal)
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, v := range row {
+			ref := fmt.Sprintf("%s%d", columnName(j), i+1)
+			if isNumber(v) {
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styles[i], cellText(v))
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, styles[i], xmlEscape(cellText(v)))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// columnName 0 -> A, 25 -> Z, 26 -> AA
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func contentTypesXML(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+const rootRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func workbookXML(names []string) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range names {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+// rId1..rIdN untuk sheet, rId(N+1) untuk styles
+func workbookRelsXML(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// stylesXML dua cellXfs: 0 normal, 1 tebal
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readXLSX isi setiap file di dalam zip xlsx
+func readXLSX(t *testing.T, doc *Document) map[string]string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+// wellFormed gagal kalau s bukan XML yang valid
+func wellFormed(t *testing.T, name, s string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("%s bukan XML valid: %v", name, err)
+		}
+	}
+}
+
+func TestWriteXLSXParts(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    *Document
+		sheets int
+	}{
+		{"tanpa section", &Document{Title: "Kosong"}, 1},
+		{"satu section", &Document{Sections: []Section{{Title: "A"}}}, 1},
+		{"tiga section", &Document{Sections: []Section{{Title: "A"}, {Title: "B"}, {Title: "C"}}}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := readXLSX(t, tt.doc)
+			for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+				content, ok := files[name]
+				if !ok {
+					t.Fatalf("%s tidak ada", name)
+				}
+				wellFormed(t, name, content)
+			}
+
+			types := files["[Content_Types].xml"]
+			if got := strings.Count(types, "spreadsheetml.worksheet+xml"); got != tt.sheets {
+				t.Errorf("override worksheet = %d, want %d", got, tt.sheets)
+			}
+			for i := 1; i <= tt.sheets; i++ {
+				name := "xl/worksheets/sheet" + string(rune('0'+i)) + ".xml"
+				if !strings.Contains(types, `PartName="/`+name+`"`) {
+					t.Errorf("[Content_Types].xml tidak punya override %s", name)
+				}
+				content, ok := files[name]
+				if !ok {
+					t.Fatalf("%s tidak ada", name)
+				}
+				wellFormed(t, name, content)
+			}
+			if len(files) != 5+tt.sheets {
+				t.Errorf("jumlah file = %d, want %d", len(files), 5+tt.sheets)
+			}
+		})
+	}
+}
+
+func TestWriteXLSXCells(t *testing.T) {
+	doc := &Document{
+		Title:    "Laporan <A & B>",
+		Subtitle: []string{`Outlet "Utama"`},
+		Sections: []Section{{
+			Title:   "Per produk",
+			Headers: []string{"Nama", "Qty", "Share"},
+			Rows: [][]interface{}{
+				{"Kopi & <Teh>", 12, 33.5},
+				{"=SUM(A1)", -3, nil},
+			},
+		}},
+	}
+	sheet := readXLSX(t, doc)["xl/worksheets/sheet1.xml"]
+	wellFormed(t, "sheet1.xml", sheet)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"judul tebal & escape", `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Laporan &lt;A &amp; B&gt;</t></is></c>`},
+		{"keterangan", `<c r="A2" s="0" t="inlineStr"><is><t xml:space="preserve">Outlet &#34;Utama&#34;</t></is></c>`},
+		{"baris kosong setelah judul", `<row r="3"></row>`},
+		{"header tebal", `<c r="B4" s="1" t="inlineStr"><is><t xml:space="preserve">Qty</t></is></c>`},
+		{"teks escape", `<c r="A5" s="0" t="inlineStr"><is><t xml:space="preserve">Kopi &amp; &lt;Teh&gt;</t></is></c>`},
+		{"int", `<c r="B5" s="0"><v>12</v></c>`},
+		{"float", `<c r="C5" s="0"><v>33.5</v></c>`},
+		{"formula tetap teks", `<c r="A6" s="0" t="inlineStr"><is><t xml:space="preserve">=SUM(A1)</t></is></c>`},
+		{"negatif", `<c r="B6" s="0"><v>-3</v></c>`},
+		{"nil kosong", `<c r="C6" s="0" t="inlineStr"><is><t xml:space="preserve"></t></is></c>`},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(sheet, tt.want) {
+			t.Errorf("%s: sheet1.xml tidak berisi %s", tt.name, tt.want)
+		}
+	}
+	if strings.Contains(sheet, "<f>") {
+		t.Error("sheet1.xml tidak boleh berisi formula")
+	}
+}
+
+func TestSheetNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		titles []string
+		want   []string
+	}{
+		{"karakter terlarang", []string{"A/B [x]: y?"}, []string{"AB x y"}},
+		{"kosong", []string{"", ""}, []string{"Sheet1", "Sheet2"}},
+		{"maksimal 31", []string{strings.Repeat("a", 40)}, []string{strings.Repeat("a", 31)}},
+		{"duplikat tanpa beda huruf besar", []string{"Data", "data", "DATA"}, []string{"Data", "data (2)", "DATA (3)"}},
+		{"duplikat panjang", []string{strings.Repeat("b", 35), strings.Repeat("b", 35)}, []string{strings.Repeat("b", 31), strings.Repeat("b", 27) + " (2)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := make([]Section, len(tt.titles))
+			for i, title := range tt.titles {
+				sections[i].Title = title
+			}
+			got := sheetNames(sections)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("sheetNames()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		in   int
+		want string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {51, "AZ"}, {52, "BA"}, {701, "ZZ"}, {702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.in); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
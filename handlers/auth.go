@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+type AuthMiddleware struct {
+	service  *services.UserService
+	required bool
+	hasUsers atomic.Bool // sekali ada user, token selalu wajib
+}
+
+// NewAuthMiddleware required false berarti request tanpa token tetap dilayani selama belum ada user sama sekali,
+// tanpa batasan outlet tetapi juga tanpa akses admin. Admin pertama dibuat lewat perintah create-admin
+func NewAuthMiddleware(service *services.UserService, required bool) *AuthMiddleware {
+	return &AuthMiddleware{service: service, required: required}
+}
+
+// Wrap cek token "Authorization: Bearer <token>" untuk semua endpoint /api/
+func (m *AuthMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if token == "" {
+			required, err := m.tokenRequired()
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			if required {
+				writeError(w, apperrors.Unauthorized("Token wajib diisi"))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := m.service.Authenticate(token)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+	})
+}
+
+// tokenRequired token wajib kalau diset lewat config atau sudah ada user,
+// supaya user yang terikat outlet tidak bisa lolos hanya dengan tidak mengirim token
+func (m *AuthMiddleware) tokenRequired() (bool, error) {
+	if m.required || m.hasUsers.Load() {
+		return true, nil
+	}
+	exists, err := m.service.HasUsers()
+	if err != nil {
+		return true, err
+	}
+	if exists {
+		m.hasUsers.Store(true)
+	}
+	return exists, nil
+}
+
+// currentUser nil kalau request tanpa token
+func currentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	return user
+}
+
+// outletScope outlet yang dipakai request: user yang terikat outlet selalu memakai outletnya sendiri
+// dan ditolak kalau meminta outlet lain, user lain memakai requested apa adanya (0 = default / semua).
+// Request tanpa token hanya lolos middleware selama belum ada user, jadi diperlakukan sama dengan user tanpa outlet
+func outletScope(r *http.Request, requested int) (int, error) {
+	user := currentUser(r)
+	if user == nil || user.OutletID == nil {
+		return requested, nil
+	}
+	if requested != 0 && requested != *user.OutletID {
+		return 0, apperrors.Forbidden("User hanya boleh mengakses outletnya sendiri")
+	}
+	return *user.OutletID, nil
+}
+
+// queryOutlet outlet_id dari query string setelah dibatasi outletScope, 0 berarti semua outlet
+func queryOutlet(r *http.Request) (int, error) {
+	requested := 0
+	if outletStr := r.URL.Query().Get("outlet_id"); outletStr != "" {
+		var err error
+		requested, err = strconv.Atoi(outletStr)
+		if err != nil {
+			return 0, apperrors.Validation(apperrors.CodeInvalid, "outlet_id", "outlet_id tidak valid")
+		}
+	}
+	return outletScope(r, requested)
+}
+
+// requireAdmin hanya user admin yang sudah login, request tanpa token selalu ditolak
+func requireAdmin(r *http.Request) error {
+	user := currentUser(r)
+	if user == nil {
+		return apperrors.Unauthorized("Login sebagai admin dulu")
+	}
+	if user.Role != models.UserRoleAdmin {
+		return apperrors.Forbidden("Hanya admin yang boleh melakukan ini")
+	}
+	return nil
+}
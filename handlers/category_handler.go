@@ -24,23 +24,25 @@ func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Reques
 	case http.MethodPost:
 		h.Create(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
 // GetAll godoc
 // @Summary Get all categories
-// @Description Get all categories
+// @Description Get all categories. Archived categories are excluded unless include_archived=true
 // @Tags categories
 // @Accept json
 // @Produce json
+// @Param include_archived query bool false "Include archived categories"
 // @Success 200 {array} models.Category
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} ErrorResponse
 // @Router /category [get]
 func (h CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAll()
+	includeArchived := r.URL.Query().Get("include_archived") == "true"
+	categories, err := h.service.GetAll(includeArchived)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -56,19 +58,20 @@ func (h CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param category body models.Category true "Category data"
 // @Success 201 {object} models.Category
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /category [post]
 func (h CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var category models.Category
 	err := json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
-		http.Error(w, "Invalid Request body", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Request body")
 		return
 	}
 
 	err = h.service.Create(&category)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -76,7 +79,39 @@ func (h CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
+// GetTree godoc
+// @Summary Get category tree
+// @Description Get all categories as a nested tree based on parent_id
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param include_archived query bool false "Include archived categories"
+// @Success 200 {array} models.Category
+// @Failure 500 {object} ErrorResponse
+// @Router /category/tree [get]
+func (h CategoryHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	includeArchived := r.URL.Query().Get("include_archived") == "true"
+	tree, err := h.service.GetTree(includeArchived)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
 func (h CategoryHandler) CategoryByID(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/restore") {
+		h.HandleRestore(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetByID(w, r)
@@ -85,32 +120,54 @@ func (h CategoryHandler) CategoryByID(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete:
 		h.Delete(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
 // GetByID godoc
 // @Summary Get category by ID
-// @Description Get a single category by ID
+// @Description Get a single category by ID. Use include=products,stats to also get its products and stock/sales statistics (sub categories included). Stats of outlet-bound users are limited to their own outlet
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
-// @Success 200 {object} models.Category
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Param include query string false "Comma separated: products, stats"
+// @Param range query string false "Sales stats named range, e.g. yesterday, last_month, ytd"
+// @Param start_date query string false "Sales stats start date (YYYY-MM-DD), requires end_date, default today"
+// @Param end_date query string false "Sales stats end date (YYYY-MM-DD), requires start_date, default today"
+// @Param outlet_id query int false "Outlet ID for stats, all outlets when empty"
+// @Success 200 {object} models.CategoryDetail
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /category/{id} [get]
 func (h CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/category/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid Category ID", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Category ID")
 		return
 	}
 
-	category, err := h.service.GetByID(id)
+	var withProducts, withStats bool
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "products":
+			withProducts = true
+		case "stats":
+			withStats = true
+		}
+	}
+
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	category, err := h.service.GetDetail(id, withProducts, withStats, queryPeriod(r), outletID)
 	if err != nil {
-		http.Error(w, "Category not found", http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
@@ -127,27 +184,29 @@ func (h CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "Category ID"
 // @Param category body models.Category true "Category data"
 // @Success 200 {object} models.Category
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /category/{id} [put]
 func (h CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/category/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid Category ID!", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Category ID!")
 		return
 	}
 
 	var category models.Category
 	err = json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
-		http.Error(w, "Invalid Request Body!", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Request Body!")
 		return
 	}
 
 	category.ID = id
 	err = h.service.Update(&category)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
@@ -157,25 +216,39 @@ func (h CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 // Delete godoc
 // @Summary Delete category
-// @Description Delete a category by ID
+// @Description Archive (soft delete) a category by ID. Rejected with 409 while it has sub categories, or while products still use it unless move_to or uncategorize=true is given
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param move_to query int false "Move products to this category ID"
+// @Param uncategorize query bool false "Remove the category from its products"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /category/{id} [delete]
 func (h CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/category/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid Category ID!", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Category ID!")
 		return
 	}
 
-	err = h.service.Delete(id)
+	moveTo := 0
+	if moveToStr := r.URL.Query().Get("move_to"); moveToStr != "" {
+		moveTo, err = strconv.Atoi(moveToStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid move_to Category ID!")
+			return
+		}
+	}
+	uncategorize := r.URL.Query().Get("uncategorize") == "true"
+
+	err = h.service.Delete(id, moveTo, uncategorize)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
@@ -184,3 +257,48 @@ func (h CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		"message": "sukses delete category",
 	})
 }
+
+func (h CategoryHandler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Restore(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// Restore godoc
+// @Summary Restore category
+// @Description Restore an archived category by ID
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /category/{id}/restore [post]
+func (h CategoryHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/category/")
+	idStr = strings.TrimSuffix(idStr, "/restore")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Category ID!")
+		return
+	}
+
+	err = h.service.Restore(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	category, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CustomerHandler struct {
+	service *services.CustomerService
+}
+
+func NewCustomerHandler(service *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+// HandleCustomers - GET/POST /api/customers
+func (h *CustomerHandler) HandleCustomers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetAll godoc
+// @Summary Get all customers
+// @Description Get all customers, optionally searched by name, phone or email
+// @Tags customers
+// @Produce json
+// @Param q query string false "Search name, phone or email"
+// @Success 200 {array} models.Customer
+// @Failure 500 {object} ErrorResponse
+// @Router /customers [get]
+func (h *CustomerHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.service.GetAll(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customers)
+}
+
+// Create godoc
+// @Summary Create a new customer
+// @Description Create a new customer. price_list sets the default price list used at checkout
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param customer body models.Customer true "Customer data"
+// @Success 201 {object} models.Customer
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /customers [post]
+func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var customer models.Customer
+	err := json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err = h.service.Create(&customer)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(customer)
+}
+
+// CustomerByID - /api/customers/{id}[/transactions|/points]
+func (h *CustomerHandler) CustomerByID(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/transactions") {
+		h.GetTransactions(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/points") {
+		h.GetLedger(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetByID godoc
+// @Summary Get customer by ID
+// @Description Get a single customer including points balance
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} models.Customer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /customers/{id} [get]
+func (h *CustomerHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := customerIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Customer ID!")
+		return
+	}
+
+	customer, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// Update godoc
+// @Summary Update customer
+// @Description Update name, phone, email and default price list of a customer. Points balance only changes through checkout
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param customer body models.Customer true "Customer data"
+// @Success 200 {object} models.Customer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /customers/{id} [put]
+func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := customerIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Customer ID!")
+		return
+	}
+
+	var customer models.Customer
+	err = json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Request Body!")
+		return
+	}
+
+	customer.ID = id
+	err = h.service.Update(&customer)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// Delete godoc
+// @Summary Delete customer
+// @Description Delete a customer that has no transactions or points history yet
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /customers/{id} [delete]
+func (h *CustomerHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := customerIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Customer ID!")
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Customer deleted successfully",
+	})
+}
+
+// GetTransactions godoc
+// @Summary Get customer purchase history
+// @Description Get all transactions of a customer with their details, newest first
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {array} models.Transaction
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /customers/{id}/transactions [get]
+func (h *CustomerHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := customerIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Customer ID!")
+		return
+	}
+
+	transactions, err := h.service.GetTransactions(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transactions)
+}
+
+// GetLedger godoc
+// @Summary Get customer points ledger
+// @Description Get every loyalty points earn and redeem entry of a customer, newest first
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {array} models.LoyaltyEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /customers/{id}/points [get]
+func (h *CustomerHandler) GetLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := customerIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Customer ID!")
+		return
+	}
+
+	entries, err := h.service.GetLedger(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// customerIDFromPath ambil {id} dari /api/customers/{id}/...
+func customerIDFromPath(r *http.Request) (int, error) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/customers/")
+	idStr, _, _ = strings.Cut(idStr, "/")
+	return strconv.Atoi(idStr)
+}
@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type OrderHandler struct {
+	service *services.OrderService
+}
+
+func NewOrderHandler(service *services.OrderService) *OrderHandler {
+	return &OrderHandler{service: service}
+}
+
+// HandleOrders - GET/POST /api/orders
+func (h *OrderHandler) HandleOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetAll godoc
+// @Summary Get all orders
+// @Description Get draft, parked, checked out and cancelled orders, newest activity first
+// @Tags orders
+// @Produce json
+// @Param status query string false "Filter by status (draft, parked, checked_out, cancelled)"
+// @Param table_id query int false "Filter by dining table"
+// @Param outlet_id query int false "Filter by outlet, outlet-bound users only see their own outlet"
+// @Success 200 {array} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders [get]
+func (h *OrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	tableID := 0
+	if tableStr := r.URL.Query().Get("table_id"); tableStr != "" {
+		var err error
+		tableID, err = strconv.Atoi(tableStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid table_id")
+			return
+		}
+	}
+
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	orders, err := h.service.GetAll(r.URL.Query().Get("status"), tableID, outletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+// Create godoc
+// @Summary Create a draft order
+// @Description Open a new draft order (items optional) in outlet_id (default outlet when empty). Set table_id for dine-in, a table can only have one open order. Stock is reserved when ORDER_STOCK_POLICY=reserve
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order body models.OrderRequest true "Order data"
+// @Success 201 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders [post]
+func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.OrderRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.OutletID, err = outletScope(r, req.OutletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	order, err := h.service.Create(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+// OrderByID - /api/orders/{id}[/items[/{itemID}]|/tickets|/receipts|/send|/park|/resume|/cancel|/checkout|/split]
+func (h *OrderHandler) OrderByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/orders/"), "/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Order ID!")
+		return
+	}
+
+	err = h.checkOutlet(r, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetByID(w, id)
+		case http.MethodDelete:
+			h.Cancel(w, id)
+		default:
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	if parts[1] == "items" {
+		h.handleItems(w, r, id, parts[2:])
+		return
+	}
+	if parts[1] == "tickets" && len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.GetTickets(w, id)
+		return
+	}
+	if parts[1] == "receipts" && len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.GetReceipts(w, id)
+		return
+	}
+
+	if len(parts) > 2 || r.Method != http.MethodPost {
+		writeErrorMessage(w, http.StatusNotFound, "Not found")
+		return
+	}
+	switch parts[1] {
+	case "send":
+		h.SendToKitchen(w, id)
+	case "park":
+		h.Park(w, id)
+	case "resume":
+		h.Resume(w, id)
+	case "cancel":
+		h.Cancel(w, id)
+	case "checkout":
+		h.Checkout(w, r, id)
+	case "split":
+		h.Split(w, r, id)
+	default:
+		writeErrorMessage(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// checkOutlet user yang terikat outlet hanya boleh mengakses order outletnya sendiri
+func (h *OrderHandler) checkOutlet(r *http.Request, id int) error {
+	user := currentUser(r)
+	if user == nil || user.OutletID == nil {
+		return nil
+	}
+
+	order, err := h.service.GetByID(id)
+	if err != nil {
+		return err
+	}
+	_, err = outletScope(r, order.OutletID)
+	return err
+}
+
+func (h *OrderHandler) handleItems(w http.ResponseWriter, r *http.Request, id int, rest []string) {
+	if len(rest) == 0 {
+		if r.Method != http.MethodPost {
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.AddItem(w, r, id)
+		return
+	}
+
+	itemID, err := strconv.Atoi(rest[0])
+	if err != nil || len(rest) > 1 {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Item ID!")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		h.UpdateItem(w, r, id, itemID)
+	case http.MethodDelete:
+		h.RemoveItem(w, id, itemID)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func writeOrder(w http.ResponseWriter, order *models.Order, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// GetByID godoc
+// @Summary Get order by ID
+// @Description Get an order with its items and estimated total (base prices, final prices are applied at checkout)
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id} [get]
+func (h *OrderHandler) GetByID(w http.ResponseWriter, id int) {
+	order, err := h.service.GetByID(id)
+	writeOrder(w, order, err)
+}
+
+// AddItem godoc
+// @Summary Add item to order
+// @Description Add a product to a draft order. Adding a product not yet sent to the kitchen increases its quantity, otherwise a new line is added for the next round
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param item body models.CheckoutItem true "Product and quantity"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/items [post]
+func (h *OrderHandler) AddItem(w http.ResponseWriter, r *http.Request, id int) {
+	var item models.CheckoutItem
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	order, err := h.service.AddItem(id, item)
+	writeOrder(w, order, err)
+}
+
+// UpdateItem godoc
+// @Summary Change item quantity
+// @Description Set the quantity of an item in a draft order, quantity 0 removes the item. Items already sent to the kitchen cannot be changed
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param itemID path int true "Order item ID"
+// @Param item body models.CheckoutItem true "New quantity (product_id is ignored)"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/items/{itemID} [put]
+func (h *OrderHandler) UpdateItem(w http.ResponseWriter, r *http.Request, id, itemID int) {
+	var item models.CheckoutItem
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	order, err := h.service.UpdateItem(id, itemID, item.Quantity)
+	writeOrder(w, order, err)
+}
+
+// RemoveItem godoc
+// @Summary Remove item from order
+// @Description Remove an item from a draft order, releasing reserved stock. Items already sent to the kitchen cannot be removed
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param itemID path int true "Order item ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/items/{itemID} [delete]
+func (h *OrderHandler) RemoveItem(w http.ResponseWriter, id, itemID int) {
+	order, err := h.service.RemoveItem(id, itemID)
+	writeOrder(w, order, err)
+}
+
+// SendToKitchen godoc
+// @Summary Send items to kitchen
+// @Description Create the next round kitchen ticket from every item not yet sent. Sent items are locked
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 201 {object} models.KitchenTicket
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/send [post]
+func (h *OrderHandler) SendToKitchen(w http.ResponseWriter, id int) {
+	ticket, err := h.service.SendToKitchen(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// GetTickets godoc
+// @Summary Get kitchen tickets of an order
+// @Description Get every kitchen ticket of an order, one per round
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.KitchenTicket
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id}/tickets [get]
+func (h *OrderHandler) GetTickets(w http.ResponseWriter, id int) {
+	tickets, err := h.service.GetTickets(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tickets)
+}
+
+// Park godoc
+// @Summary Park order
+// @Description Park a draft order so the cashier can serve other customers. Reserved stock stays reserved
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/park [post]
+func (h *OrderHandler) Park(w http.ResponseWriter, id int) {
+	order, err := h.service.Park(id)
+	writeOrder(w, order, err)
+}
+
+// Resume godoc
+// @Summary Resume parked order
+// @Description Move a parked order back to draft so its items can be edited
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/resume [post]
+func (h *OrderHandler) Resume(w http.ResponseWriter, id int) {
+	order, err := h.service.Resume(id)
+	writeOrder(w, order, err)
+}
+
+// Cancel godoc
+// @Summary Cancel order
+// @Description Cancel a draft or parked order and release its reserved stock. Also available as DELETE /orders/{id}
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) Cancel(w http.ResponseWriter, id int) {
+	order, err := h.service.Cancel(id)
+	writeOrder(w, order, err)
+}
+
+// Checkout godoc
+// @Summary Checkout order
+// @Description Turn a draft or parked order into a transaction using the same rules as /checkout (price lists, tiers, loyalty). Table orders must have every item sent to the kitchen first
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param request body models.OrderCheckoutRequest false "Points to redeem"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/checkout [post]
+func (h *OrderHandler) Checkout(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.OrderCheckoutRequest
+	if r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	transaction, err := h.service.Checkout(id, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// Split godoc
+// @Summary Split bill
+// @Description Settle an order with several payers. mode=items turns every part into its own transaction (all item quantities must be allocated, tier prices are computed per part). mode=even creates one transaction split into "ways" equal payments (2 to 50), remainder rupiah go to the first payers; when the amount due is smaller than "ways" the last payers get a 0 share, and an amount due of 0 (fully paid with points) is rejected. Each payer gets a receipt
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param request body models.SplitRequest true "Split definition"
+// @Success 200 {object} models.SplitResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/split [post]
+func (h *OrderHandler) Split(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.SplitRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result, err := h.service.Split(id, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetReceipts godoc
+// @Summary Get order receipts
+// @Description Get one receipt per payment of a settled order (one for a normal checkout, several for split bills)
+// @Tags orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.Receipt
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id}/receipts [get]
+func (h *OrderHandler) GetReceipts(w http.ResponseWriter, id int) {
+	receipts, err := h.service.GetReceipts(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipts)
+}
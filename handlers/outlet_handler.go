@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type OutletHandler struct {
+	service *services.OutletService
+}
+
+func NewOutletHandler(service *services.OutletService) *OutletHandler {
+	return &OutletHandler{service: service}
+}
+
+// HandleOutlets - GET/POST /api/outlets
+func (h *OutletHandler) HandleOutlets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetAll godoc
+// @Summary Get all outlets
+// @Description Get all outlets. Archived outlets are excluded unless include_archived=true
+// @Tags outlets
+// @Produce json
+// @Param include_archived query bool false "Include archived outlets"
+// @Success 200 {array} models.Outlet
+// @Failure 500 {object} ErrorResponse
+// @Router /outlets [get]
+func (h *OutletHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outlets, err := h.service.GetAll(r.URL.Query().Get("include_archived") == "true")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outlets)
+}
+
+// Create godoc
+// @Summary Create an outlet
+// @Description Create a new outlet (admin only)
+// @Tags outlets
+// @Accept json
+// @Produce json
+// @Param outlet body models.Outlet true "Outlet data"
+// @Success 201 {object} models.Outlet
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /outlets [post]
+func (h *OutletHandler) Create(w http.ResponseWriter, r *http.Request) {
+	err := requireAdmin(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var outlet models.Outlet
+	err = json.NewDecoder(r.Body).Decode(&outlet)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err = h.service.Create(&outlet)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(outlet)
+}
+
+// OutletByID - /api/outlets/{id}[/stock|/restore]
+func (h *OutletHandler) OutletByID(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/outlets/")
+	idStr, sub, _ := strings.Cut(idStr, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Outlet ID!")
+		return
+	}
+
+	switch sub {
+	case "stock":
+		_, err := outletScope(r, id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.GetStock(w, id)
+		case http.MethodPut:
+			if err := requireAdmin(r); err != nil {
+				writeError(w, err)
+				return
+			}
+			h.SetStock(w, r, id)
+		default:
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	case "restore":
+		if r.Method != http.MethodPost {
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.Restore(w, r, id)
+		return
+	case "":
+	default:
+		writeErrorMessage(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, id)
+	case http.MethodPut:
+		h.Update(w, r, id)
+	case http.MethodDelete:
+		h.Delete(w, r, id)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetByID godoc
+// @Summary Get outlet by ID
+// @Description Get a single outlet
+// @Tags outlets
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Success 200 {object} models.Outlet
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /outlets/{id} [get]
+func (h *OutletHandler) GetByID(w http.ResponseWriter, id int) {
+	outlet, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outlet)
+}
+
+// Update godoc
+// @Summary Update outlet
+// @Description Update code, name and address of an outlet (admin only). The default outlet code cannot change
+// @Tags outlets
+// @Accept json
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Param outlet body models.Outlet true "Outlet data"
+// @Success 200 {object} models.Outlet
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /outlets/{id} [put]
+func (h *OutletHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	err := requireAdmin(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var outlet models.Outlet
+	err = json.NewDecoder(r.Body).Decode(&outlet)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Request Body!")
+		return
+	}
+
+	outlet.ID = id
+	err = h.service.Update(&outlet)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.GetByID(w, id)
+}
+
+// Delete godoc
+// @Summary Archive outlet
+// @Description Archive an outlet without stock, open orders or shipped transfers in either direction (admin only). The default outlet cannot be archived
+// @Tags outlets
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /outlets/{id} [delete]
+func (h *OutletHandler) Delete(w http.ResponseWriter, r *http.Request, id int) {
+	err := requireAdmin(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Outlet archived successfully",
+	})
+}
+
+// Restore godoc
+// @Summary Restore outlet
+// @Description Restore an archived outlet (admin only)
+// @Tags outlets
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Success 200 {object} models.Outlet
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /outlets/{id}/restore [post]
+func (h *OutletHandler) Restore(w http.ResponseWriter, r *http.Request, id int) {
+	err := requireAdmin(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = h.service.Restore(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.GetByID(w, id)
+}
+
+// GetStock godoc
+// @Summary Get outlet stock
+// @Description Get stock, reserved stock and available stock of every active product in an outlet
+// @Tags outlets
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Success 200 {array} models.OutletStock
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /outlets/{id}/stock [get]
+func (h *OutletHandler) GetStock(w http.ResponseWriter, id int) {
+	stocks, err := h.service.GetStock(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stocks)
+}
+
+// SetStock godoc
+// @Summary Set outlet stock
+// @Description Set the stock level of one or more products in an outlet (admin only). Product total stock follows the change
+// @Tags outlets
+// @Accept json
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Param stock body []models.OutletStockUpdate true "New stock levels"
+// @Success 200 {array} models.OutletStock
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /outlets/{id}/stock [put]
+func (h *OutletHandler) SetStock(w http.ResponseWriter, r *http.Request, id int) {
+	var updates []models.OutletStockUpdate
+	err := json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err = h.service.SetStock(id, updates)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.GetStock(w, id)
+}
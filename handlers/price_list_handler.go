@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+)
+
+type PriceListHandler struct {
+	service *services.PriceListService
+}
+
+func NewPriceListHandler(service *services.PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+// HandlePriceLists - GET/POST /api/price-lists
+func (h *PriceListHandler) HandlePriceLists(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetAll godoc
+// @Summary Get all price lists
+// @Description Get all price lists (retail, wholesale, member, ...)
+// @Tags price lists
+// @Produce json
+// @Success 200 {array} models.PriceList
+// @Failure 500 {object} ErrorResponse
+// @Router /price-lists [get]
+func (h *PriceListHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	priceLists, err := h.service.GetAll()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(priceLists)
+}
+
+// Create godoc
+// @Summary Create a price list
+// @Description Create a new price list
+// @Tags price lists
+// @Accept json
+// @Produce json
+// @Param priceList body models.PriceList true "Price list data"
+// @Success 201 {object} models.PriceList
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /price-lists [post]
+func (h *PriceListHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var priceList models.PriceList
+	err := json.NewDecoder(r.Body).Decode(&priceList)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err = h.service.Create(&priceList)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(priceList)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"kasir-api/models"
 	"kasir-api/services"
 	"net/http"
@@ -25,24 +26,39 @@ func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request)
 	case http.MethodPost:
 		h.Create(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
 // GetAll godoc
 // @Summary Get all products
-// @Description Get all products with category information
+// @Description Get all products with category information. Archived products are excluded unless include_archived=true
 // @Tags products
 // @Accept json
 // @Produce json
+// @Param name query string false "Filter by product name"
+// @Param category_id query int false "Filter by category, including its sub categories"
+// @Param include_archived query bool false "Include archived products"
 // @Success 200 {array} models.Product
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} ErrorResponse
 // @Router /products [get]
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	products, err := h.service.GetAll(name)
+	filter := models.ProductFilter{
+		Name:            r.URL.Query().Get("name"),
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+	}
+	if categoryIDStr := r.URL.Query().Get("category_id"); categoryIDStr != "" {
+		categoryID, err := strconv.Atoi(categoryIDStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid Category ID!")
+			return
+		}
+		filter.CategoryID = categoryID
+	}
+
+	products, err := h.service.GetAll(filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -58,19 +74,20 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param product body models.Product true "Product data"
 // @Success 201 {object} models.Product
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /products [post]
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var product models.Product
 	err := json.NewDecoder(r.Body).Decode(&product)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	err = h.service.Create(&product)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
@@ -81,106 +98,191 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 // Handler ProductByID pakai switch method
 func (h *ProductHandler) ProductByID(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/restore") {
+		h.HandleRestore(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/price-history") {
+		h.GetPriceHistory(w, r)
+		return
+	}
+	if strings.Contains(r.URL.Path, "/price-schedules") {
+		h.HandlePriceSchedules(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/prices") {
+		h.HandlePrices(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetByID(w, r)
 	case http.MethodPut:
 		h.Update(w, r)
+	case http.MethodPatch:
+		h.Patch(w, r)
 	case http.MethodDelete:
 		h.Delete(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
 // GetByID godoc
 // @Summary Get product by ID
-// @Description Get a single product by ID with category information
+// @Description Get a single product by ID with category information. The ETag header holds the product version for If-Match
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} models.Product
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Header 200 {string} ETag "Product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /products/{id} [get]
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid Product ID!", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
 		return
 	}
 
 	product, err := h.service.GetByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
+	setETag(w, product.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(product)
 }
 
 // Update godoc
 // @Summary Update product
-// @Description Update an existing product by ID
+// @Description Replace an existing product by ID. Send If-Match (or version in the body) to reject stale updates with 412
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from GET /products/{id}"
 // @Param product body models.Product true "Product data"
 // @Success 200 {object} models.Product
-// @Failure 400 {object} map[string]string
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Router /products/{id} [put]
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid Product ID!", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid If-Match header!")
 		return
 	}
 
 	var product models.Product
 	err = json.NewDecoder(r.Body).Decode(&product)
 	if err != nil {
-		http.Error(w, "Invalid Request Body!", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Request Body!")
 		return
 	}
 
 	product.ID = id
+	if version > 0 {
+		product.Version = version
+	}
 	err = h.service.Update(&product)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// Patch godoc
+// @Summary Partially update product
+// @Description Update only the supplied fields of a product. Send If-Match (or version in the body) to reject stale updates with 412
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from GET /products/{id}"
+// @Param product body models.ProductPatch true "Fields to update"
+// @Success 200 {object} models.Product
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Router /products/{id} [patch]
+func (h *ProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid If-Match header!")
+		return
+	}
+
+	var patch models.ProductPatch
+	err = json.NewDecoder(r.Body).Decode(&patch)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Request Body!")
+		return
+	}
+
+	product, err := h.service.Patch(id, patch, version)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(product)
 }
 
 // Delete godoc
 // @Summary Delete product
-// @Description Delete a product by ID
+// @Description Archive (soft delete) a product by ID. Archived products are hidden from listings and checkout but kept for reports. The product is removed from draft and parked orders and their stock reservations are released; archiving is rejected with 409 while the product is on an open order already sent to the kitchen
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /products/{id} [delete]
 func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid Product ID!", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
 		return
 	}
 
 	err = h.service.Delete(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -189,3 +291,294 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		"message": "Product deleted successfully",
 	})
 }
+
+func (h *ProductHandler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.Restore(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// Restore godoc
+// @Summary Restore product
+// @Description Restore an archived product by ID
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/restore [post]
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
+	idStr = strings.TrimSuffix(idStr, "/restore")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	err = h.service.Restore(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	product, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// GetPriceHistory godoc
+// @Summary Get product price history
+// @Description Get every price change of a product, newest first
+// @Tags products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.PriceHistory
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/price-history [get]
+func (h *ProductHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := productIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	history, err := h.service.GetPriceHistory(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// HandlePriceSchedules - /api/products/{id}/price-schedules[/{scheduleID}]
+func (h *ProductHandler) HandlePriceSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetPriceSchedules(w, r)
+	case http.MethodPost:
+		h.CreatePriceSchedule(w, r)
+	case http.MethodDelete:
+		h.CancelPriceSchedule(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetPriceSchedules godoc
+// @Summary Get scheduled price changes
+// @Description Get pending, applied and cancelled price schedules of a product
+// @Tags products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.PriceSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/price-schedules [get]
+func (h *ProductHandler) GetPriceSchedules(w http.ResponseWriter, r *http.Request) {
+	id, err := productIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	schedules, err := h.service.GetPriceSchedules(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// CreatePriceSchedule godoc
+// @Summary Schedule a price change
+// @Description Schedule a new price that is applied automatically at effective_at (RFC3339)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param schedule body models.PriceSchedule true "new_price and effective_at"
+// @Success 201 {object} models.PriceSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/price-schedules [post]
+func (h *ProductHandler) CreatePriceSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := productIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	var schedule models.PriceSchedule
+	err = json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	schedule.ProductID = id
+	err = h.service.SchedulePriceChange(&schedule)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// CancelPriceSchedule godoc
+// @Summary Cancel a scheduled price change
+// @Description Cancel a price schedule that has not been applied yet
+// @Tags products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param scheduleID path int true "Schedule ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/price-schedules/{scheduleID} [delete]
+func (h *ProductHandler) CancelPriceSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := productIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	scheduleIDStr := strings.TrimPrefix(r.URL.Path, fmt.Sprintf("/api/products/%d/price-schedules/", id))
+	scheduleID, err := strconv.Atoi(scheduleIDStr)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Schedule ID!")
+		return
+	}
+
+	err = h.service.CancelPriceSchedule(id, scheduleID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Price schedule cancelled",
+	})
+}
+
+// HandlePrices - /api/products/{id}/prices
+func (h *ProductHandler) HandlePrices(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetPrices(w, r)
+	case http.MethodPut:
+		h.SetPrices(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetPrices godoc
+// @Summary Get product price tiers
+// @Description Get the price list and quantity-break prices of a product. The product price field is the retail fallback
+// @Tags products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ProductPriceTier
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/prices [get]
+func (h *ProductHandler) GetPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := productIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	tiers, err := h.service.GetPrices(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tiers)
+}
+
+// SetPrices godoc
+// @Summary Replace product price tiers
+// @Description Replace all price list and quantity-break prices of a product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param tiers body []models.ProductPriceTier true "price_list, min_qty and price of each tier"
+// @Success 200 {array} models.ProductPriceTier
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /products/{id}/prices [put]
+func (h *ProductHandler) SetPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := productIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Product ID!")
+		return
+	}
+
+	var tiers []models.ProductPriceTier
+	err = json.NewDecoder(r.Body).Decode(&tiers)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err = h.service.SetPrices(id, tiers)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tiers)
+}
+
+// productIDFromPath ambil {id} dari /api/products/{id}/...
+func productIDFromPath(r *http.Request) (int, error) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
+	idStr, _, _ = strings.Cut(idStr, "/")
+	return strconv.Atoi(idStr)
+}
+
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf("%q", strconv.Itoa(version)))
+}
+
+// ifMatchVersion ambil versi dari header If-Match ("3" atau W/"3"), 0 kalau tidak dikirim atau "*"
+func ifMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	value = strings.TrimPrefix(value, "W/")
+	return strconv.Atoi(strings.Trim(value, `"`))
+}
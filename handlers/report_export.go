@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/export"
+	"kasir-api/models"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// exportFormat format export dari parameter format, atau dari header Accept kalau format kosong.
+// String kosong berarti JSON seperti biasa
+func exportFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	switch format {
+	case "", "json":
+	case export.FormatCSV, export.FormatXLSX, export.FormatPDF:
+		return format, nil
+	default:
+		return "", apperrors.Validation(apperrors.CodeInvalid, "format", "format harus json, csv, xlsx atau pdf")
+	}
+	if format == "json" {
+		return "", nil
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(accept), ";")
+		for f, contentType := range export.ContentTypes {
+			if base, _, _ := strings.Cut(contentType, ";"); strings.EqualFold(mediaType, base) {
+				return f, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// writeExport tulis doc sebagai file download, dirender ke buffer dulu supaya error masih bisa dibalas JSON
+func writeExport(w http.ResponseWriter, format, filename string, doc *export.Document) {
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, doc); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Println("Gagal mengirim export:", err)
+	}
+}
+
+// exportSubtitle keterangan periode dan outlet di bawah judul export
+func exportSubtitle(startDate, endDate string, outletID int) []string {
+	outlet := "Semua outlet"
+	if outletID != 0 {
+		outlet = fmt.Sprintf("Outlet ID %d", outletID)
+	}
+	return []string{fmt.Sprintf("Periode: %s s/d %s", startDate, endDate), outlet}
+}
+
+// label group_by untuk judul export
+var groupByLabels = map[string]string{
+	"hour":  "jam",
+	"day":   "hari",
+	"week":  "minggu",
+	"month": "bulan",
+}
+
+func reportDocument(report *models.Report, outletID int) *export.Document {
+	doc := &export.Document{
+		Title:    "Laporan Penjualan",
+		Subtitle: exportSubtitle(report.StartDate, report.EndDate, outletID),
+	}
+
+	averageBasket := 0
+	if report.TotalTransaction > 0 {
+		averageBasket = report.TotalRevenue / report.TotalTransaction
+	}
+	summary := export.Section{
+		Title:   "Ringkasan",
+		Headers: []string{"Metrik", "Nilai"},
+		Rows: [][]interface{}{
+			{"Total omzet", report.TotalRevenue},
+			{"Jumlah transaksi", report.TotalTransaction},
+			{"Rata-rata belanja", averageBasket},
+		},
+	}
+	if c := report.Comparison; c != nil {
+		summary.Headers = []string{"Metrik", "Nilai", fmt.Sprintf("Pembanding (%s s/d %s)", c.StartDate, c.EndDate), "Selisih", "Selisih %"}
+		for i, delta := range []models.MetricDelta{c.TotalRevenue, c.TotalTransaction, c.AverageBasket} {
+			summary.Rows[i] = append(summary.Rows[i], delta.Previous, delta.Change, percentCell(delta.ChangePct))
+		}
+	}
+	doc.Sections = append(doc.Sections, summary,
+		productSalesSection("Terlaris (qty)", report.TopByQty),
+		productSalesSection("Terlaris (omzet)", report.TopByRevenue),
+		productSalesSection("Paling lambat terjual", report.SlowMovers),
+	)
+
+	zero := export.Section{Title: "Tidak terjual", Headers: []string{"No", "Produk ID", "Produk"}}
+	for _, p := range report.ZeroSales {
+		zero.Rows = append(zero.Rows, []interface{}{p.Rank, p.ProductID, p.Nama})
+	}
+	doc.Sections = append(doc.Sections, zero)
+	return doc
+}
+
+func productSalesSection(title string, sales []models.ProductSales) export.Section {
+	section := export.Section{Title: title, Headers: []string{"Rank", "Produk ID", "Produk", "Qty", "Omzet"}}
+	for _, p := range sales {
+		section.Rows = append(section.Rows, []interface{}{p.Rank, p.ProductID, p.Nama, p.QtyTerjual, p.Revenue})
+	}
+	return section
+}
+
+// percentCell persen kosong (bukan 0) kalau tidak bisa dihitung
+func percentCell(pct *float64) interface{} {
+	if pct == nil {
+		return ""
+	}
+	return *pct
+}
+
+func seriesDocument(series *models.SalesSeries, outletID int) *export.Document {
+	layout := "2006-01-02"
+	switch series.GroupBy {
+	case "hour":
+		layout = "2006-01-02 15:04"
+	case "month":
+		layout = "2006-01"
+	}
+
+	bucketSection := func(title string, buckets []models.SalesBucket) export.Section {
+		section := export.Section{Title: title, Headers: []string{"Periode", "Omzet", "Transaksi", "Item terjual", "Rata-rata belanja"}}
+		for _, b := range buckets {
+			section.Rows = append(section.Rows, []interface{}{b.Period.Format(layout), b.Revenue, b.TransactionCount, b.ItemsSold, b.AverageBasket})
+		}
+		return section
+	}
+
+	doc := &export.Document{
+		Title:    "Tren Penjualan",
+		Subtitle: exportSubtitle(series.StartDate, series.EndDate, outletID),
+	}
+	if c := series.Comparison; c != nil {
+		summary := export.Section{
+			Title:   "Ringkasan",
+			Headers: []string{"Metrik", "Nilai", fmt.Sprintf("Pembanding (%s s/d %s)", c.StartDate, c.EndDate), "Selisih", "Selisih %"},
+		}
+		metrics := []struct {
+			label string
+			delta models.MetricDelta
+		}{
+			{"Total omzet", c.TotalRevenue},
+			{"Jumlah transaksi", c.TotalTransaction},
+			{"Item terjual", c.ItemsSold},
+			{"Rata-rata belanja", c.AverageBasket},
+		}
+		for _, m := range metrics {
+			summary.Rows = append(summary.Rows, []interface{}{m.label, m.delta.Current, m.delta.Previous, m.delta.Change, percentCell(m.delta.ChangePct)})
+		}
+		doc.Sections = append(doc.Sections, summary)
+	}
+	doc.Sections = append(doc.Sections, bucketSection("Tren penjualan per "+groupByLabels[series.GroupBy], series.Buckets))
+	if c := series.Comparison; c != nil {
+		doc.Sections = append(doc.Sections, bucketSection(fmt.Sprintf("Pembanding %s s/d %s", c.StartDate, c.EndDate), c.Buckets))
+	}
+	return doc
+}
+
+func breakdownDocument(breakdown *models.SalesBreakdown, outletID int) *export.Document {
+	section := export.Section{Title: "Per kategori", Headers: []string{"No", "Kategori", "Qty", "Share qty %", "Omzet", "Share omzet %"}}
+	if breakdown.By == models.BreakdownByProduct {
+		section = export.Section{Title: "Per produk", Headers: []string{"No", "Produk", "Kategori", "Qty", "Share qty %", "Omzet", "Share omzet %"}}
+	}
+	c := breakdown.Comparison
+	if c != nil {
+		section.Headers = append(section.Headers, "Qty pembanding", "Omzet pembanding", "Selisih omzet", "Selisih omzet %")
+	}
+	for i, b := range breakdown.Rows {
+		row := []interface{}{i + 1, b.Nama}
+		if breakdown.By == models.BreakdownByProduct {
+			row = append(row, b.CategoryName)
+		}
+		row = append(row, b.QtyTerjual, b.QtyShare, b.Revenue, b.RevenueShare)
+		if b.Comparison != nil {
+			row = append(row, b.Comparison.QtyTerjual.Previous, b.Comparison.Revenue.Previous, b.Comparison.Revenue.Change, percentCell(b.Comparison.Revenue.ChangePct))
+		}
+		section.Rows = append(section.Rows, row)
+	}
+	total := []interface{}{"", "Total"}
+	if breakdown.By == models.BreakdownByProduct {
+		total = append(total, "")
+	}
+	total = append(total, breakdown.TotalQty, "", breakdown.TotalRevenue, "")
+	if c != nil {
+		total = append(total, c.TotalQty.Previous, c.TotalRevenue.Previous, c.TotalRevenue.Change, percentCell(c.TotalRevenue.ChangePct))
+	}
+	section.Rows = append(section.Rows, total)
+
+	subtitle := exportSubtitle(breakdown.StartDate, breakdown.EndDate, outletID)
+	if c != nil {
+		subtitle = append(subtitle, fmt.Sprintf("Pembanding: %s s/d %s", c.StartDate, c.EndDate))
+	}
+
+	return &export.Document{
+		Title:    "Breakdown Penjualan",
+		Subtitle: subtitle,
+		Sections: []export.Section{section},
+	}
+}
+
+func zReportDocument(z *models.ZReport) *export.Document {
+	doc := &export.Document{
+		Title: fmt.Sprintf("Z Report #%d", z.Sequence),
+		Subtitle: []string{
+			fmt.Sprintf("Outlet: %s", z.OutletName),
+			fmt.Sprintf("Hari bisnis: %s", z.BusinessDate),
+			fmt.Sprintf("Ditutup: %s", z.ClosedAt.Format("2006-01-02 15:04:05")),
+		},
+	}
+	if z.Note != "" {
+		doc.Subtitle = append(doc.Subtitle, "Catatan: "+z.Note)
+	}
+
+	transactions := "-"
+	if z.FirstTransactionID != nil && z.LastTransactionID != nil {
+		transactions = fmt.Sprintf("#%d s/d #%d", *z.FirstTransactionID, *z.LastTransactionID)
+	}
+	doc.Sections = append(doc.Sections, export.Section{
+		Title:   "Penjualan",
+		Headers: []string{"Metrik", "Nilai"},
+		Rows: [][]interface{}{
+			{"Jumlah transaksi", z.TransactionCount},
+			{"Nomor transaksi", transactions},
+			{"Item terjual", z.ItemsSold},
+			{"Penjualan kotor", z.GrossSales},
+			{"Poin ditukar", z.PointsRedeemed},
+			{"Potongan poin", z.PointsDiscount},
+			{"Penjualan bersih", z.NetSales},
+			{"Rata-rata belanja", z.AverageBasket},
+		},
+	})
+
+	payments := export.Section{Title: "Pembayaran", Headers: []string{"Metode", "Transaksi", "Jumlah"}}
+	for _, p := range z.Payments {
+		payments.Rows = append(payments.Rows, []interface{}{p.Method, p.TransactionCount, p.Amount})
+	}
+	payments.Rows = append(payments.Rows, []interface{}{"Total", z.TransactionCount, z.NetSales})
+	doc.Sections = append(doc.Sections, payments)
+	return doc
+}
+
+// intCell nilai int opsional untuk sel export, kosong kalau nil
+func intCell(v *int) interface{} {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func inventoryDocument(report *models.InventoryReport, outletID int) *export.Document {
+	section := export.Section{Title: "Per produk", Headers: []string{"No", "Produk", "Kategori", "Stok", "Harga", "Harga pokok",
+		"Nilai jual", "Nilai pokok", "Qty terjual", "Per hari", "Hari cover", "Hari sejak terjual"}}
+	if report.By == models.InventoryByCategory {
+		section = export.Section{Title: "Per kategori", Headers: []string{"No", "Kategori", "Produk", "Stok",
+			"Nilai jual", "Nilai pokok", "Tanpa harga pokok", "Qty terjual", "Per hari", "Hari cover", "Hari sejak terjual"}}
+	}
+	for i, r := range report.Rows {
+		row := []interface{}{i + 1, r.Nama}
+		if report.By == models.InventoryByCategory {
+			row = append(row, r.ProductCount, r.Stock, r.RetailValue, intCell(r.CostValue), r.CostUnknownProducts)
+		} else {
+			row = append(row, r.CategoryName, r.Stock, r.Price, intCell(r.Cost), r.RetailValue, intCell(r.CostValue))
+		}
+		section.Rows = append(section.Rows, append(row, r.QtyTerjual, r.DailyVelocity, percentCell(r.DaysOfCover), intCell(r.DaysSinceLastSale)))
+	}
+	var total []interface{}
+	if report.By == models.InventoryByCategory {
+		total = []interface{}{"", "Total", "", report.TotalStock, report.TotalRetailValue, report.TotalCostValue, report.CostUnknownProducts, "", "", "", ""}
+	} else {
+		total = []interface{}{"", "Total", "", report.TotalStock, "", "", report.TotalRetailValue, report.TotalCostValue, "", "", "", ""}
+	}
+	section.Rows = append(section.Rows, total)
+
+	outlet := "Semua outlet"
+	if outletID != 0 {
+		outlet = fmt.Sprintf("Outlet ID %d", outletID)
+	}
+	return &export.Document{
+		Title: "Laporan Persediaan",
+		Subtitle: []string{
+			fmt.Sprintf("Kecepatan jual: %d hari (%s s/d %s)", report.Days, report.WindowStart, report.WindowEnd),
+			outlet,
+			fmt.Sprintf("Nilai pokok tanpa %d produk yang belum punya harga pokok", report.CostUnknownProducts),
+		},
+		Sections: []export.Section{section},
+	}
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"kasir-api/models"
 	"kasir-api/services"
 	"net/http"
+	"strconv"
 )
 
 type ReportHandler struct {
@@ -16,38 +19,330 @@ func NewReportHandler(service *services.ReportService) *ReportHandler {
 
 // HandleReport godoc
 // @Summary Get sales report
-// @Description Get daily report or report by date range
+// @Description Get daily report or report by date range with the top products by quantity and by revenue, the slowest movers and active products without sales. Dates and "today" follow the store timezone (STORE_TIMEZONE), ranges are limited to 366 days. With compare, revenue, transaction count and average basket of the comparison period are returned with absolute and percentage deltas. Users bound to an outlet only see their own outlet
 // @Tags Report
-// @Produce json
-// @Param start_date query string false "Start date (YYYY-MM-DD)"
-// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param range query string false "Named range: today, yesterday, this_week, last_week, this_month, last_month, mtd, ytd, last_7_days, last_30_days"
+// @Param start_date query string false "Start date (YYYY-MM-DD), requires end_date, default today"
+// @Param end_date query string false "End date (YYYY-MM-DD), requires start_date, default today"
+// @Param outlet_id query int false "Outlet ID, all outlets when empty"
+// @Param top query int false "Number of products in each ranking (default 5, max 100)"
+// @Param compare query string false "Add a comparison period: previous (same length right before) or last_year (same dates a year earlier)"
+// @Param format query string false "json (default), csv, xlsx or pdf. Also negotiated from the Accept header"
 // @Success 200 {object} models.Report
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /report [get]
 // @Router /report/hari-ini [get]
 func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	topN := 0
+	if topStr := r.URL.Query().Get("top"); topStr != "" {
+		topN, err = strconv.Atoi(topStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid top")
+			return
+		}
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	report, err := h.service.GetReportByDateRange(queryPeriod(r), r.URL.Query().Get("compare"), outletID, topN)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format != "" {
+		writeExport(w, format, fmt.Sprintf("laporan-penjualan_%s_%s", report.StartDate, report.EndDate), reportDocument(report, outletID))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleCategoryRollup godoc
+// @Summary Get sales report per category
+// @Description Get revenue and quantity sold for each category directly under parent_id (top level when empty), including sales of all their sub categories. With compare, every category gets the quantity and revenue of the comparison period with absolute and percentage deltas
+// @Tags Report
+// @Produce json
+// @Param parent_id query int false "Parent category ID"
+// @Param range query string false "Named range: today, yesterday, this_week, last_week, this_month, last_month, mtd, ytd, last_7_days, last_30_days"
+// @Param start_date query string false "Start date (YYYY-MM-DD), requires end_date, default today"
+// @Param end_date query string false "End date (YYYY-MM-DD), requires start_date, default today"
+// @Param outlet_id query int false "Outlet ID, all outlets when empty"
+// @Param compare query string false "Add a comparison period: previous (same length right before) or last_year (same dates a year earlier)"
+// @Success 200 {array} models.CategoryRollup
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /report/category-tree [get]
+func (h *ReportHandler) HandleCategoryRollup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	parentID := 0
+	if parentIDStr := r.URL.Query().Get("parent_id"); parentIDStr != "" {
+		var err error
+		parentID, err = strconv.Atoi(parentIDStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid parent_id")
+			return
+		}
+	}
+
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	report, err := h.service.GetCategoryRollup(queryPeriod(r), r.URL.Query().Get("compare"), parentID, outletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleSalesSeries godoc
+// @Summary Get sales time series
+// @Description Get revenue, transaction count, items sold and average basket per hour, day, week (starting Monday) or month in the store timezone. Periods without sales are returned with zeros. With compare, the series of the comparison period with the same group_by is returned too, along with deltas of the period totals
+// @Tags Report
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param group_by query string false "hour, day, week or month (default day)"
+// @Param range query string false "Named range: today, yesterday, this_week, last_week, this_month, last_month, mtd, ytd, last_7_days, last_30_days"
+// @Param start_date query string false "Start date (YYYY-MM-DD), requires end_date, default today"
+// @Param end_date query string false "End date (YYYY-MM-DD), requires start_date, default today"
+// @Param outlet_id query int false "Outlet ID, all outlets when empty"
+// @Param compare query string false "Add a comparison period: previous (same length right before) or last_year (same dates a year earlier)"
+// @Param format query string false "json (default), csv, xlsx or pdf. Also negotiated from the Accept header"
+// @Success 200 {object} models.SalesSeries
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /report/sales [get]
+func (h *ReportHandler) HandleSalesSeries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	series, err := h.service.GetSalesSeries(queryPeriod(r), r.URL.Query().Get("group_by"), r.URL.Query().Get("compare"), outletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format != "" {
+		writeExport(w, format, fmt.Sprintf("tren-penjualan_%s_%s", series.StartDate, series.EndDate), seriesDocument(series, outletID))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// HandleSalesBreakdown godoc
+// @Summary Get sales breakdown per category or product
+// @Description Get quantity, revenue and share of the period total (percent) per category or per product, sortable and paginated. Revenue is the sum of item subtotals before points redemption. Products without category are grouped under category ID 0. With compare, every row gets its quantity and revenue in the comparison period and the totals of the comparison period are returned, all with absolute and percentage deltas
+// @Tags Report
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param by query string false "category or product (default category)"
+// @Param range query string false "Named range: today, yesterday, this_week, last_week, this_month, last_month, mtd, ytd, last_7_days, last_30_days"
+// @Param start_date query string false "Start date (YYYY-MM-DD), requires end_date, default today"
+// @Param end_date query string false "End date (YYYY-MM-DD), requires start_date, default today"
+// @Param outlet_id query int false "Outlet ID, all outlets when empty"
+// @Param compare query string false "Add a comparison period: previous (same length right before) or last_year (same dates a year earlier)"
+// @Param sort query string false "revenue, qty or name (default revenue)"
+// @Param order query string false "asc or desc (default desc, asc for name)"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Rows per page (default 20, max 100)"
+// @Param format query string false "json (default), csv, xlsx or pdf. Exports contain all rows, ignoring page and page_size. Also negotiated from the Accept header"
+// @Success 200 {object} models.SalesBreakdown
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /report/breakdown [get]
+func (h *ReportHandler) HandleSalesBreakdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	q := models.BreakdownQuery{
+		By:       query.Get("by"),
+		Period:   queryPeriod(r),
+		Compare:  query.Get("compare"),
+		OutletID: outletID,
+		Sort:     query.Get("sort"),
+		Order:    query.Get("order"),
+	}
+	if pageStr := query.Get("page"); pageStr != "" {
+		q.Page, err = strconv.Atoi(pageStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid page")
+			return
+		}
+	}
+	if sizeStr := query.Get("page_size"); sizeStr != "" {
+		q.PageSize, err = strconv.Atoi(sizeStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid page_size")
+			return
+		}
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	q.All = format != ""
+
+	breakdown, err := h.service.GetSalesBreakdown(q)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format != "" {
+		filename := fmt.Sprintf("breakdown-%s_%s_%s", breakdown.By, breakdown.StartDate, breakdown.EndDate)
+		writeExport(w, format, filename, breakdownDocument(breakdown, outletID))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(breakdown)
+}
+
+// HandleInventory godoc
+// @Summary Get inventory valuation and stock aging report
+// @Description Get current stock per product or per category, valued at selling price and at cost where the product cost is known, with sales velocity over the last days (including today), estimated days of cover and the business date and days since the last sale, all read from the daily sales summary. Without outlet_id the stock is the total across outlets and sales from all outlets are counted. Archived products are excluded
+// @Tags Report
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param by query string false "product or category (default product)"
+// @Param outlet_id query int false "Outlet ID, all outlets when empty"
+// @Param days query int false "Sales velocity window in days (default 30, max 365)"
+// @Param sort query string false "value, stock, cover, velocity, aging or name (default value)"
+// @Param order query string false "asc or desc (default desc, asc for name and cover)"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Rows per page (default 20, max 100)"
+// @Param format query string false "json (default), csv, xlsx or pdf. Exports contain all rows, ignoring page and page_size. Also negotiated from the Accept header"
+// @Success 200 {object} models.InventoryReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /report/inventory [get]
+func (h *ReportHandler) HandleInventory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	startDate := r.URL.Query().Get("start_date")
-	endDate := r.URL.Query().Get("end_date")
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
-	var report interface{}
-	var err error
+	query := r.URL.Query()
+	q := models.InventoryQuery{
+		By:       query.Get("by"),
+		OutletID: outletID,
+		Sort:     query.Get("sort"),
+		Order:    query.Get("order"),
+	}
+	if daysStr := query.Get("days"); daysStr != "" {
+		q.Days, err = strconv.Atoi(daysStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+	}
+	if pageStr := query.Get("page"); pageStr != "" {
+		q.Page, err = strconv.Atoi(pageStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid page")
+			return
+		}
+	}
+	if sizeStr := query.Get("page_size"); sizeStr != "" {
+		q.PageSize, err = strconv.Atoi(sizeStr)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid page_size")
+			return
+		}
+	}
 
-	if startDate != "" && endDate != "" {
-		report, err = h.service.GetReportByDateRange(startDate, endDate)
-	} else {
-		report, err = h.service.GetDailyReport()
+	format, err := exportFormat(r)
+	if err != nil {
+		writeError(w, err)
+		return
 	}
+	q.All = format != ""
 
+	report, err := h.service.GetInventory(q)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	if format != "" {
+		filename := fmt.Sprintf("persediaan-%s_%s", report.By, report.WindowEnd)
+		writeExport(w, format, filename, inventoryDocument(report, outletID))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(report)
 }
+
+// queryPeriod ambil range, start_date dan end_date dari query string, validasi di service
+func queryPeriod(r *http.Request) models.PeriodQuery {
+	query := r.URL.Query()
+	return models.PeriodQuery{
+		Range:     query.Get("range"),
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+	}
+}
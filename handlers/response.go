@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/apperrors"
+	"log"
+	"net/http"
+)
+
+// ErrorResponse format error untuk semua endpoint
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// writeError status ditentukan dari jenis error domain,
+// error lain (misal database down) dibalas 500 tanpa detail
+func writeError(w http.ResponseWriter, err error) {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		log.Println("Internal error:", err)
+		writeErrorMessage(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(appErr, apperrors.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(appErr, apperrors.ErrValidation):
+		status = http.StatusBadRequest
+	case errors.Is(appErr, apperrors.ErrConflict), errors.Is(appErr, apperrors.ErrInsufficientStock):
+		status = http.StatusConflict
+	case errors.Is(appErr, apperrors.ErrPreconditionFailed):
+		status = http.StatusPreconditionFailed
+	case errors.Is(appErr, apperrors.ErrUnauthorized):
+		status = http.StatusUnauthorized
+	case errors.Is(appErr, apperrors.ErrForbidden):
+		status = http.StatusForbidden
+	}
+
+	writeErrorDetail(w, status, ErrorDetail{
+		Code:    appErr.Code,
+		Message: appErr.Message,
+		Field:   appErr.Field,
+	})
+}
+
+// writeErrorMessage untuk error yang terjadi di handler (ID tidak valid, body rusak, dll)
+func writeErrorMessage(w http.ResponseWriter, status int, message string) {
+	writeErrorDetail(w, status, ErrorDetail{Code: errorCode(status), Message: message})
+}
+
+func writeErrorDetail(w http.ResponseWriter, status int, detail ErrorDetail) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: detail})
+}
+
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return apperrors.CodeUnauthorized
+	case http.StatusForbidden:
+		return apperrors.CodeForbidden
+	case http.StatusNotFound:
+		return apperrors.CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusConflict:
+		return apperrors.CodeConflict
+	case http.StatusPreconditionFailed:
+		return apperrors.CodePreconditionFailed
+	default:
+		return "internal_error"
+	}
+}
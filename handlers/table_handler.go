@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type TableHandler struct {
+	service *services.TableService
+}
+
+func NewTableHandler(service *services.TableService) *TableHandler {
+	return &TableHandler{service: service}
+}
+
+// HandleTables - GET/POST /api/tables
+func (h *TableHandler) HandleTables(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetAll godoc
+// @Summary Get all dining tables
+// @Description Get all dining tables with their open order, if any. Archived tables are excluded unless include_archived=true
+// @Tags tables
+// @Produce json
+// @Param include_archived query bool false "Include archived tables"
+// @Success 200 {array} models.DiningTable
+// @Failure 500 {object} ErrorResponse
+// @Router /tables [get]
+func (h *TableHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	tables, err := h.service.GetAll(r.URL.Query().Get("include_archived") == "true")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tables)
+}
+
+// Create godoc
+// @Summary Create a dining table
+// @Description Create a dining table, name is the table number shown to staff
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param table body models.DiningTable true "Table data"
+// @Success 201 {object} models.DiningTable
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tables [post]
+func (h *TableHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var table models.DiningTable
+	err := json.NewDecoder(r.Body).Decode(&table)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err = h.service.Create(&table)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(table)
+}
+
+// TableByID - /api/tables/{id}[/restore]
+func (h *TableHandler) TableByID(w http.ResponseWriter, r *http.Request) {
+	id, err := tableIDFromPath(r)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Table ID!")
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/restore") {
+		if r.Method != http.MethodPost {
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h.Restore(w, id)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, id)
+	case http.MethodPut:
+		h.Update(w, r, id)
+	case http.MethodDelete:
+		h.Delete(w, id)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetByID godoc
+// @Summary Get dining table by ID
+// @Description Get a single dining table with its open order, if any
+// @Tags tables
+// @Produce json
+// @Param id path int true "Table ID"
+// @Success 200 {object} models.DiningTable
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /tables/{id} [get]
+func (h *TableHandler) GetByID(w http.ResponseWriter, id int) {
+	table, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
+
+// Update godoc
+// @Summary Update dining table
+// @Description Update name and seats of a dining table
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param id path int true "Table ID"
+// @Param table body models.DiningTable true "Table data"
+// @Success 200 {object} models.DiningTable
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tables/{id} [put]
+func (h *TableHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	var table models.DiningTable
+	err := json.NewDecoder(r.Body).Decode(&table)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Request Body!")
+		return
+	}
+
+	table.ID = id
+	err = h.service.Update(&table)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.GetByID(w, id)
+}
+
+// Delete godoc
+// @Summary Archive dining table
+// @Description Archive a dining table that has no open order
+// @Tags tables
+// @Produce json
+// @Param id path int true "Table ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tables/{id} [delete]
+func (h *TableHandler) Delete(w http.ResponseWriter, id int) {
+	err := h.service.Delete(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Table archived successfully",
+	})
+}
+
+// Restore godoc
+// @Summary Restore dining table
+// @Description Restore an archived dining table
+// @Tags tables
+// @Produce json
+// @Param id path int true "Table ID"
+// @Success 200 {object} models.DiningTable
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /tables/{id}/restore [post]
+func (h *TableHandler) Restore(w http.ResponseWriter, id int) {
+	err := h.service.Restore(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.GetByID(w, id)
+}
+
+// tableIDFromPath ambil {id} dari /api/tables/{id}/...
+func tableIDFromPath(r *http.Request) (int, error) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/tables/")
+	idStr, _, _ = strings.Cut(idStr, "/")
+	return strconv.Atoi(idStr)
+}
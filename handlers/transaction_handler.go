@@ -17,21 +17,24 @@ func NewTransactionHandler(service *services.TransactionService) *TransactionHan
 
 // HandleCheckout godoc
 // @Summary Checkout transaction
-// @Description Create new transaction with items
+// @Description Create new transaction with items. Unit prices come from price_list (retail when empty) using the quantity-break tier that matches each item. With customer_id the customer earns loyalty points and can pay part of the bill with redeem_points. Stock is taken from outlet_id (default outlet when empty, always the user's own outlet for outlet-bound users). payment_method is cash (default), card, qris or transfer
 // @Tags Transaction
 // @Accept json
 // @Produce json
 // @Param request body models.CheckoutRequest true "Checkout Request"
 // @Success 200 {object} models.Transaction
-// @Failure 400 {string} string "Invalid request body"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Insufficient stock or business day already closed"
+// @Failure 500 {object} ErrorResponse
 // @Router /checkout/ [post]
 func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
 		h.Checkout(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
@@ -39,13 +42,19 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	var req models.CheckoutRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items, false)
+	req.OutletID, err = outletScope(r, req.OutletID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	transaction, err := h.service.Checkout(req)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
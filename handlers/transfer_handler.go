@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type TransferHandler struct {
+	service *services.TransferService
+}
+
+func NewTransferHandler(service *services.TransferService) *TransferHandler {
+	return &TransferHandler{service: service}
+}
+
+// HandleTransfers - GET/POST /api/transfers
+func (h *TransferHandler) HandleTransfers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetAll godoc
+// @Summary Get all stock transfers
+// @Description Get stock transfers, newest first. outlet_id matches both outgoing and incoming transfers
+// @Tags transfers
+// @Produce json
+// @Param status query string false "Filter by status (draft, shipped, received, cancelled)"
+// @Param outlet_id query int false "Filter by source or destination outlet"
+// @Success 200 {array} models.StockTransfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /transfers [get]
+func (h *TransferHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	transfers, err := h.service.GetAll(r.URL.Query().Get("status"), outletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+// Create godoc
+// @Summary Create a stock transfer
+// @Description Create a draft transfer from one outlet to another. Outlet-bound users can only send from their own outlet
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param transfer body models.StockTransferRequest true "Transfer data"
+// @Success 201 {object} models.StockTransfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /transfers [post]
+func (h *TransferHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.StockTransferRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.FromOutletID, err = outletScope(r, req.FromOutletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	transfer, err := h.service.Create(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// TransferByID - /api/transfers/{id}[/ship|/receive|/cancel]
+func (h *TransferHandler) TransferByID(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/transfers/")
+	idStr, action, _ := strings.Cut(idStr, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Transfer ID!")
+		return
+	}
+
+	transfer, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if action == "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.GetByID(w, r, transfer)
+		case http.MethodPut:
+			h.Update(w, r, transfer)
+		case http.MethodDelete:
+			h.Cancel(w, r, transfer)
+		default:
+			writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	switch action {
+	case "ship":
+		h.Ship(w, r, transfer)
+	case "receive":
+		h.Receive(w, r, transfer)
+	case "cancel":
+		h.Cancel(w, r, transfer)
+	default:
+		writeErrorMessage(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func writeTransfer(w http.ResponseWriter, transfer *models.StockTransfer, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// GetByID godoc
+// @Summary Get stock transfer by ID
+// @Description Get a stock transfer with its lines and receiving discrepancies
+// @Tags transfers
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /transfers/{id} [get]
+func (h *TransferHandler) GetByID(w http.ResponseWriter, r *http.Request, transfer *models.StockTransfer) {
+	_, errFrom := outletScope(r, transfer.FromOutletID)
+	_, errTo := outletScope(r, transfer.ToOutletID)
+	if errFrom != nil && errTo != nil {
+		writeError(w, apperrors.Forbidden("User hanya boleh mengakses outletnya sendiri"))
+		return
+	}
+	writeTransfer(w, transfer, nil)
+}
+
+// Update godoc
+// @Summary Update stock transfer
+// @Description Replace outlets, note and lines of a draft transfer
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Param transfer body models.StockTransferRequest true "Transfer data"
+// @Success 200 {object} models.StockTransfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /transfers/{id} [put]
+func (h *TransferHandler) Update(w http.ResponseWriter, r *http.Request, transfer *models.StockTransfer) {
+	_, err := outletScope(r, transfer.FromOutletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req models.StockTransferRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.FromOutletID, err = outletScope(r, req.FromOutletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updated, err := h.service.Update(transfer.ID, req)
+	writeTransfer(w, updated, err)
+}
+
+// Ship godoc
+// @Summary Ship stock transfer
+// @Description Ship a draft transfer, stock leaves the source outlet immediately. Both outlets must be active (409)
+// @Tags transfers
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /transfers/{id}/ship [post]
+func (h *TransferHandler) Ship(w http.ResponseWriter, r *http.Request, transfer *models.StockTransfer) {
+	_, err := outletScope(r, transfer.FromOutletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	shipped, err := h.service.Ship(transfer.ID)
+	writeTransfer(w, shipped, err)
+}
+
+// Receive godoc
+// @Summary Receive stock transfer
+// @Description Receive a shipped transfer at the destination outlet. Lines not listed are received in full. A received quantity lower than the shipped quantity is recorded as a discrepancy, only the received quantity is added to the destination stock. The shortage is written off against the source outlet, or returned to the source stock when return_shortage is true (shortage_action). Receiving more than was shipped is rejected, as is receiving into an archived outlet (409)
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Param request body models.ReceiveTransferRequest false "Received quantities"
+// @Success 200 {object} models.StockTransfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /transfers/{id}/receive [post]
+func (h *TransferHandler) Receive(w http.ResponseWriter, r *http.Request, transfer *models.StockTransfer) {
+	_, err := outletScope(r, transfer.ToOutletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req models.ReceiveTransferRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	received, err := h.service.Receive(transfer.ID, req)
+	writeTransfer(w, received, err)
+}
+
+// Cancel godoc
+// @Summary Cancel stock transfer
+// @Description Cancel a draft transfer. Also available as DELETE /transfers/{id}
+// @Tags transfers
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.StockTransfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /transfers/{id}/cancel [post]
+func (h *TransferHandler) Cancel(w http.ResponseWriter, r *http.Request, transfer *models.StockTransfer) {
+	_, err := outletScope(r, transfer.FromOutletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	cancelled, err := h.service.Cancel(transfer.ID)
+	writeTransfer(w, cancelled, err)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ZReportHandler struct {
+	service *services.ZReportService
+}
+
+func NewZReportHandler(service *services.ZReportService) *ZReportHandler {
+	return &ZReportHandler{service: service}
+}
+
+// HandleZReports - GET/POST /api/z-reports
+func (h *ZReportHandler) HandleZReports(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Close(w, r)
+	default:
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetAll godoc
+// @Summary Get Z reports
+// @Description Get end-of-day Z reports whose business date falls in the period, newest first. Users bound to an outlet only see their own outlet
+// @Tags z-reports
+// @Produce json
+// @Param outlet_id query int false "Outlet ID, all outlets when empty"
+// @Param range query string false "Named range: today, yesterday, this_week, last_week, this_month, last_month, mtd, ytd, last_7_days, last_30_days"
+// @Param start_date query string false "Business date from (YYYY-MM-DD), requires end_date, default today"
+// @Param end_date query string false "Business date to (YYYY-MM-DD), requires start_date, default today"
+// @Success 200 {array} models.ZReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /z-reports [get]
+func (h *ZReportHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outletID, err := queryOutlet(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	reports, err := h.service.GetAll(outletID, queryPeriod(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// Close godoc
+// @Summary Close a business day
+// @Description Close the business day of an outlet and store its Z report with the next sequence number: sales, items sold, points redeemed and payments per method. Refunds and taxes are not included until the POS records them. The report can never be changed, and checkouts at the outlet are rejected with 409 until the next business day starts. Business days follow the store timezone
+// @Tags z-reports
+// @Accept json
+// @Produce json
+// @Param request body models.ZReportRequest true "Outlet and business date (default today)"
+// @Success 201 {object} models.ZReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /z-reports [post]
+func (h *ZReportHandler) Close(w http.ResponseWriter, r *http.Request) {
+	var req models.ZReportRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.OutletID, err = outletScope(r, req.OutletID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var closedBy *int
+	if user := currentUser(r); user != nil {
+		closedBy = &user.ID
+	}
+
+	report, err := h.service.Close(req, closedBy)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetByID godoc
+// @Summary Get Z report by ID
+// @Description Get a stored Z report, optionally as a printable PDF, CSV or XLSX
+// @Tags z-reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param id path int true "Z report ID"
+// @Param format query string false "json (default), csv, xlsx or pdf. Also negotiated from the Accept header"
+// @Success 200 {object} models.ZReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /z-reports/{id} [get]
+func (h *ZReportHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/z-reports/"))
+	if err != nil {
+		writeErrorMessage(w, http.StatusBadRequest, "Invalid Z report ID")
+		return
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	report, err := h.service.GetByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, err := outletScope(r, report.OutletID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format != "" {
+		writeExport(w, format, fmt.Sprintf("z-report_outlet-%d_%04d", report.OutletID, report.Sequence), zReportDocument(report))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
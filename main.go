@@ -2,15 +2,19 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"kasir-api/database"
 	"kasir-api/handlers"
+	"kasir-api/models"
 	"kasir-api/repositories"
 	"kasir-api/services"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
+	_ "time/tzdata" // data zona waktu ikut di binary, image container belum tentu punya
 
 	_ "kasir-api/docs" // Import generated docs
 
@@ -33,8 +37,13 @@ import (
 // @BasePath /api
 
 type Config struct {
-	Port   string `mapstructure:"PORT"`
-	DBConn string `mapstructure:"DB_CONN"`
+	Port              string `mapstructure:"PORT"`
+	DBConn            string `mapstructure:"DB_CONN"`
+	LoyaltyEarnPer    int    `mapstructure:"LOYALTY_EARN_PER"`
+	LoyaltyPointValue int    `mapstructure:"LOYALTY_POINT_VALUE"`
+	OrderStockPolicy  string `mapstructure:"ORDER_STOCK_POLICY"`
+	AuthRequired      bool   `mapstructure:"AUTH_REQUIRED"`
+	StoreTimezone     string `mapstructure:"STORE_TIMEZONE"`
 }
 
 func main() {
@@ -42,14 +51,35 @@ func main() {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
+	// default: 1 poin setiap belanja Rp10.000, 1 poin = Rp100
+	viper.SetDefault("LOYALTY_EARN_PER", 10000)
+	viper.SetDefault("LOYALTY_POINT_VALUE", 100)
+	// "reserve" = stok item order langsung ditahan, "none" = stok baru dipotong saat checkout
+	viper.SetDefault("ORDER_STOCK_POLICY", "none")
+	// true = semua endpoint /api/ wajib pakai token user. Begitu ada user, token selalu wajib.
+	// Admin pertama dibuat lewat: go run . create-admin -username <nama>
+	viper.SetDefault("AUTH_REQUIRED", false)
+	// batas "hari ini" dan pengelompokan laporan pakai zona waktu toko, bukan zona waktu server/database
+	viper.SetDefault("STORE_TIMEZONE", "Asia/Jakarta")
+
 	if _, err := os.Stat(".env"); err == nil {
 		viper.SetConfigFile(".env")
 		_ = viper.ReadInConfig()
 	}
 
 	config := Config{
-		Port:   viper.GetString("PORT"),
-		DBConn: viper.GetString("DB_CONN"),
+		Port:              viper.GetString("PORT"),
+		DBConn:            viper.GetString("DB_CONN"),
+		LoyaltyEarnPer:    viper.GetInt("LOYALTY_EARN_PER"),
+		LoyaltyPointValue: viper.GetInt("LOYALTY_POINT_VALUE"),
+		OrderStockPolicy:  viper.GetString("ORDER_STOCK_POLICY"),
+		AuthRequired:      viper.GetBool("AUTH_REQUIRED"),
+		StoreTimezone:     viper.GetString("STORE_TIMEZONE"),
+	}
+
+	storeLocation, err := time.LoadLocation(config.StoreTimezone)
+	if err != nil {
+		log.Fatal("STORE_TIMEZONE tidak valid:", err)
 	}
 
 	// setup database nya
@@ -68,28 +98,104 @@ func main() {
 		})
 	})
 
-	fmt.Println("Server running di http://localhost:" + config.Port)
 	productRepo := repositories.NewProductRepository(db)
-	productService := services.NewProductService(productRepo)
-	productHandler := handlers.NewProductHandler(productService)
 	categoryRepo := repositories.NewCategoryRepository(db)
-	categoryService := services.NewCategoryService(categoryRepo)
+	priceListRepo := repositories.NewPriceListRepository(db)
+	productService := services.NewProductService(productRepo, categoryRepo, priceListRepo)
+	productHandler := handlers.NewProductHandler(productService)
+	categoryService := services.NewCategoryService(categoryRepo, productRepo, storeLocation)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
-	transactionRepo := repositories.NewTransactionRepository(db)
-	transactionService := services.NewTransactionService(transactionRepo)
+	transactionRepo := repositories.NewTransactionRepository(db, storeLocation)
+	loyalty := models.LoyaltyConfig{
+		EarnPer:    config.LoyaltyEarnPer,
+		PointValue: config.LoyaltyPointValue,
+	}
+	transactionService := services.NewTransactionService(transactionRepo, loyalty)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
-	reportRepo := repositories.NewReportRepository(db)
-	reportService := services.NewReportService(reportRepo)
+	reportRepo := repositories.NewReportRepository(db, storeLocation)
+	reportService := services.NewReportService(reportRepo, storeLocation)
 	reportHandler := handlers.NewReportHandler(reportService)
 
+	// go run . backfill-summary [-start YYYY-MM-DD -end YYYY-MM-DD]: hitung ulang ringkasan penjualan harian lalu keluar.
+	// Jalankan ulang untuk semua tanggal kalau STORE_TIMEZONE diganti
+	if len(os.Args) > 1 && os.Args[1] == "backfill-summary" {
+		if db == nil {
+			log.Fatal("Database tidak tersedia")
+		}
+		runBackfillSummary(reportService, os.Args[2:])
+		return
+	}
+	priceListService := services.NewPriceListService(priceListRepo)
+	priceListHandler := handlers.NewPriceListHandler(priceListService)
+	customerRepo := repositories.NewCustomerRepository(db)
+	customerService := services.NewCustomerService(customerRepo, transactionRepo)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+	orderRepo := repositories.NewOrderRepository(db, storeLocation)
+	orderService := services.NewOrderService(orderRepo, strings.EqualFold(config.OrderStockPolicy, "reserve"), loyalty)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	tableRepo := repositories.NewTableRepository(db)
+	tableService := services.NewTableService(tableRepo)
+	tableHandler := handlers.NewTableHandler(tableService)
+	outletRepo := repositories.NewOutletRepository(db)
+	outletService := services.NewOutletService(outletRepo)
+	outletHandler := handlers.NewOutletHandler(outletService)
+	transferRepo := repositories.NewTransferRepository(db)
+	transferService := services.NewTransferService(transferRepo)
+	transferHandler := handlers.NewTransferHandler(transferService)
+	userRepo := repositories.NewUserRepository(db)
+	userService := services.NewUserService(userRepo)
+	userHandler := handlers.NewUserHandler(userService)
+
+	// go run . create-admin -username <nama> [-name <nama lengkap>]: buat user admin, tokennya dicetak sekali lalu keluar
+	if len(os.Args) > 1 && os.Args[1] == "create-admin" {
+		if db == nil {
+			log.Fatal("Database tidak tersedia")
+		}
+		runCreateAdmin(userService, os.Args[2:])
+		return
+	}
+	zReportRepo := repositories.NewZReportRepository(db)
+	zReportService := services.NewZReportService(zReportRepo, storeLocation)
+	zReportHandler := handlers.NewZReportHandler(zReportService)
+	authMiddleware := handlers.NewAuthMiddleware(userService, config.AuthRequired)
+
 	// Register routes
 	http.HandleFunc("/api/products", productHandler.HandleProducts)
 	http.HandleFunc("/api/products/", productHandler.ProductByID)
 	http.HandleFunc("/api/category", categoryHandler.HandleCategories)
+	http.HandleFunc("/api/category/tree", categoryHandler.GetTree)
 	http.HandleFunc("/api/category/", categoryHandler.CategoryByID)
+	http.HandleFunc("/api/price-lists", priceListHandler.HandlePriceLists)
+	http.HandleFunc("/api/customers", customerHandler.HandleCustomers)
+	http.HandleFunc("/api/customers/", customerHandler.CustomerByID)
+	http.HandleFunc("/api/orders", orderHandler.HandleOrders)
+	http.HandleFunc("/api/orders/", orderHandler.OrderByID)
+	http.HandleFunc("/api/tables", tableHandler.HandleTables)
+	http.HandleFunc("/api/tables/", tableHandler.TableByID)
+	http.HandleFunc("/api/outlets", outletHandler.HandleOutlets)
+	http.HandleFunc("/api/outlets/", outletHandler.OutletByID)
+	http.HandleFunc("/api/transfers", transferHandler.HandleTransfers)
+	http.HandleFunc("/api/transfers/", transferHandler.TransferByID)
+	http.HandleFunc("/api/users", userHandler.HandleUsers)
+	http.HandleFunc("/api/users/", userHandler.Delete)
+	http.HandleFunc("/api/z-reports", zReportHandler.HandleZReports)
+	http.HandleFunc("/api/z-reports/", zReportHandler.GetByID)
 	http.HandleFunc("/api/checkout/", transactionHandler.HandleCheckout)
 	http.HandleFunc("/api/report/hari-ini", reportHandler.HandleReport)
 	http.HandleFunc("/api/report", reportHandler.HandleReport)
+	http.HandleFunc("/api/report/category-tree", reportHandler.HandleCategoryRollup)
+	http.HandleFunc("/api/report/sales", reportHandler.HandleSalesSeries)
+	http.HandleFunc("/api/report/breakdown", reportHandler.HandleSalesBreakdown)
+	http.HandleFunc("/api/report/inventory", reportHandler.HandleInventory)
+
+	// jadwal perubahan harga dicek setiap menit
+	if db != nil {
+		go productService.RunPriceScheduler(time.Minute)
+
+		if err := reportService.EnsureDailySummary(); err != nil {
+			log.Println("Gagal mengisi ringkasan harian:", err)
+		}
+	}
 
 	// Swagger documentation routes
 	http.Handle("/swagger/", http.StripPrefix("/swagger/", http.FileServer(http.Dir("./docs/"))))
@@ -102,8 +208,42 @@ func main() {
 		http.Redirect(w, r, "/swagger/", http.StatusMovedPermanently)
 	})
 
-	err = http.ListenAndServe(":"+config.Port, nil)
+	fmt.Println("Server running di http://localhost:" + config.Port)
+	err = http.ListenAndServe(":"+config.Port, authMiddleware.Wrap(http.DefaultServeMux))
 	if err != nil {
 		log.Fatal("Gagal running server:", err)
 	}
 }
+
+// runBackfillSummary perintah backfill-summary, tanpa -start dan -end semua tanggal sejak transaksi pertama
+func runBackfillSummary(reportService *services.ReportService, args []string) {
+	fs := flag.NewFlagSet("backfill-summary", flag.ExitOnError)
+	start := fs.String("start", "", "tanggal awal (YYYY-MM-DD)")
+	end := fs.String("end", "", "tanggal akhir (YYYY-MM-DD), inklusif")
+	fs.Parse(args)
+
+	startDate, endDate, count, err := reportService.RebuildDailySummary(*start, *end)
+	if err != nil {
+		log.Fatal("Gagal backfill ringkasan harian:", err)
+	}
+	if startDate == "" {
+		log.Println("Belum ada transaksi, ringkasan harian tidak diubah")
+		return
+	}
+	log.Printf("Ringkasan harian %s s/d %s dihitung ulang dari %d transaksi\n", startDate, endDate, count)
+}
+
+// runCreateAdmin perintah create-admin, satu-satunya cara membuat admin tanpa token admin lain
+func runCreateAdmin(userService *services.UserService, args []string) {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := fs.String("username", "", "username admin")
+	name := fs.String("name", "", "nama lengkap")
+	fs.Parse(args)
+
+	user := models.User{Username: *username, Name: *name, Role: models.UserRoleAdmin}
+	err := userService.Create(&user)
+	if err != nil {
+		log.Fatal("Gagal membuat admin:", err)
+	}
+	fmt.Printf("Admin %s dibuat, token (hanya ditampilkan sekali): %s\n", user.Username, user.Token)
+}
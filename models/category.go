@@ -1,7 +1,31 @@
 package models
 
+import "time"
+
 type Category struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	ParentID    *int       `json:"parent_id"`
+	Archived    bool       `json:"archived"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	Children    []Category `json:"children,omitempty"`
+}
+
+// CategoryDetail kategori beserta produk dan statistiknya (termasuk sub kategori)
+type CategoryDetail struct {
+	Category
+	Products []Product      `json:"products,omitempty"`
+	Stats    *CategoryStats `json:"stats,omitempty"`
+}
+
+type CategoryStats struct {
+	ProductCount     int    `json:"product_count"`
+	TotalStock       int    `json:"total_stock"`
+	TotalStockValue  int    `json:"total_stock_value"`
+	StartDate        string `json:"start_date"`
+	EndDate          string `json:"end_date"`
+	TotalRevenue     int    `json:"total_revenue"`
+	TotalTransaction int    `json:"total_transaksi"`
+	QtyTerjual       int    `json:"qty_terjual"`
 }
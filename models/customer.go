@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type Customer struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	Phone         string    `json:"phone"`
+	Email         string    `json:"email"`
+	PriceList     string    `json:"price_list,omitempty"` // daftar harga default saat checkout
+	PointsBalance int       `json:"points_balance"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LoyaltyEntry satu baris buku poin, points negatif untuk penukaran
+type LoyaltyEntry struct {
+	ID            int       `json:"id"`
+	CustomerID    int       `json:"customer_id"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	Points        int       `json:"points"`
+	Reason        string    `json:"reason"` // earn / redeem
+	BalanceAfter  int       `json:"balance_after"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LoyaltyConfig aturan poin: dapat 1 poin setiap EarnPer rupiah yang dibayar,
+// 1 poin bernilai PointValue rupiah saat ditukar
+type LoyaltyConfig struct {
+	EarnPer    int
+	PointValue int
+}
@@ -0,0 +1,129 @@
+package models
+
+import "time"
+
+// Status order
+const (
+	OrderDraft      = "draft"
+	OrderParked     = "parked"
+	OrderCheckedOut = "checked_out"
+	OrderCancelled  = "cancelled"
+)
+
+type Order struct {
+	ID            int         `json:"id"`
+	Status        string      `json:"status"`
+	CustomerID    *int        `json:"customer_id,omitempty"`
+	TableID       *int        `json:"table_id,omitempty"`
+	TableName     string      `json:"table_name,omitempty"`
+	OutletID      int         `json:"outlet_id"`
+	PriceList     string      `json:"price_list,omitempty"`
+	Note          string      `json:"note"`
+	StockReserved bool        `json:"stock_reserved"`
+	TransactionID *int        `json:"transaction_id,omitempty"` // kosong kalau dibayar split per item, lihat receipts
+	Items         []OrderItem `json:"items"`
+	// EstimatedTotal pakai harga dasar produk, harga final (tier/daftar harga) dihitung saat checkout
+	EstimatedTotal int       `json:"estimated_total"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type OrderItem struct {
+	ID          int    `json:"id"`
+	OrderID     int    `json:"order_id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+	Price       int    `json:"price"`
+	Subtotal    int    `json:"subtotal"`
+	// TicketID terisi setelah item dikirim ke dapur, item yang sudah dikirim tidak bisa diubah
+	TicketID *int `json:"ticket_id,omitempty"`
+}
+
+type OrderRequest struct {
+	CustomerID int            `json:"customer_id,omitempty"`
+	TableID    int            `json:"table_id,omitempty"`  // order dine-in, satu meja hanya boleh punya satu order terbuka
+	OutletID   int            `json:"outlet_id,omitempty"` // default outlet utama
+	PriceList  string         `json:"price_list,omitempty"`
+	Note       string         `json:"note"`
+	Items      []CheckoutItem `json:"items"`
+}
+
+type OrderCheckoutRequest struct {
+	RedeemPoints  int    `json:"redeem_points,omitempty"`
+	PaymentMethod string `json:"payment_method,omitempty"`
+}
+
+// KitchenTicket item yang dikirim ke dapur dalam satu ronde
+type KitchenTicket struct {
+	ID        int                 `json:"id"`
+	OrderID   int                 `json:"order_id"`
+	TableName string              `json:"table_name,omitempty"`
+	Round     int                 `json:"round"`
+	Items     []KitchenTicketItem `json:"items"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+type KitchenTicketItem struct {
+	OrderItemID int    `json:"order_item_id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// Mode split bill
+const (
+	SplitByItems = "items"
+	SplitEven    = "even"
+)
+
+// SplitRequest mode items: tiap parts jadi transaksi sendiri,
+// mode even: satu transaksi dibagi rata ke Ways pembayaran
+type SplitRequest struct {
+	Mode          string      `json:"mode"`
+	Parts         []SplitPart `json:"parts,omitempty"`
+	Ways          int         `json:"ways,omitempty"`           // 2 sampai 50, bagian bisa 0 kalau sisa bayar lebih kecil
+	RedeemPoints  int         `json:"redeem_points,omitempty"`  // khusus mode even
+	PaymentMethod string      `json:"payment_method,omitempty"` // khusus mode even, berlaku untuk semua pembayar
+}
+
+type SplitPart struct {
+	CustomerID    int         `json:"customer_id,omitempty"` // default customer order
+	RedeemPoints  int         `json:"redeem_points,omitempty"`
+	PaymentMethod string      `json:"payment_method,omitempty"`
+	Items         []SplitItem `json:"items"`
+}
+
+type SplitItem struct {
+	OrderItemID int `json:"order_item_id"`
+	Quantity    int `json:"quantity"`
+}
+
+// Payment satu pembayaran atas transaksi, bagian Part dari Parts
+type Payment struct {
+	ID            int       `json:"id"`
+	TransactionID int       `json:"transaction_id"`
+	OrderID       *int      `json:"order_id,omitempty"`
+	Part          int       `json:"part"`
+	Parts         int       `json:"parts"`
+	Amount        int       `json:"amount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Receipt struk untuk satu pembayar, Payment.Amount yang harus dibayar pembayar ini
+type Receipt struct {
+	Payment        Payment             `json:"payment"`
+	Items          []TransactionDetail `json:"items"`
+	TotalAmount    int                 `json:"total_amount"`
+	PointsRedeemed int                 `json:"points_redeemed"`
+	PointsEarned   int                 `json:"points_earned"`
+	AmountDue      int                 `json:"amount_due"`
+	PaymentMethod  string              `json:"payment_method"`
+}
+
+type SplitResult struct {
+	OrderID      int           `json:"order_id"`
+	Mode         string        `json:"mode"`
+	Transactions []Transaction `json:"transactions"`
+	Receipts     []Receipt     `json:"receipts"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type Outlet struct {
+	ID         int        `json:"id"`
+	Code       string     `json:"code"`
+	Name       string     `json:"name"`
+	Address    string     `json:"address"`
+	Archived   bool       `json:"archived"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// OutletStock stok satu produk di satu outlet, Available = stok yang belum di-reserve order
+type OutletStock struct {
+	OutletID      int    `json:"outlet_id"`
+	ProductID     int    `json:"product_id"`
+	ProductName   string `json:"product_name"`
+	Stock         int    `json:"stock"`
+	ReservedStock int    `json:"reserved_stock"`
+	Available     int    `json:"available"`
+}
+
+// OutletStockUpdate set stok produk di outlet ke nilai baru
+type OutletStockUpdate struct {
+	ProductID int `json:"product_id"`
+	Stock     int `json:"stock"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+type PriceHistory struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"product_id"`
+	OldPrice   *int      `json:"old_price"`
+	NewPrice   int       `json:"new_price"`
+	Source     string    `json:"source"` // manual / schedule
+	ScheduleID *int      `json:"schedule_id,omitempty"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+type PriceSchedule struct {
+	ID          int        `json:"id"`
+	ProductID   int        `json:"product_id"`
+	NewPrice    int        `json:"new_price"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	Status      string     `json:"status"` // pending / applied / cancelled
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// PriceList daftar harga, misal retail / wholesale / member
+type PriceList struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// ProductPriceTier harga produk di satu daftar harga mulai dari qty tertentu
+type ProductPriceTier struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	PriceList string `json:"price_list"`
+	MinQty    int    `json:"min_qty"`
+	Price     int    `json:"price"`
+}
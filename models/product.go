@@ -1,11 +1,33 @@
 package models
 
+import "time"
+
 type Product struct {
-	ID                  int    `json:"id"`
-	Name                string `json:"name"`
-	Price               int    `json:"price"`
-	Stock               int    `json:"stock"`
-	CategoryID          int    `json:"category_id"`
-	CategoryName        string `json:"category_name,omitempty"`
-	CategoryDescription string `json:"category_description,omitempty"`
+	ID                  int        `json:"id"`
+	Name                string     `json:"name"`
+	Price               int        `json:"price"`
+	Stock               int        `json:"stock"` // total semua outlet
+	Cost                *int       `json:"cost"`  // harga pokok per unit, null kalau belum diketahui
+	CategoryID          int        `json:"category_id"`
+	CategoryName        string     `json:"category_name,omitempty"`
+	CategoryDescription string     `json:"category_description,omitempty"`
+	Archived            bool       `json:"archived"`
+	ArchivedAt          *time.Time `json:"archived_at,omitempty"`
+	Version             int        `json:"version"`
+}
+
+// ProductPatch field yang nil tidak diubah
+type ProductPatch struct {
+	Name       *string `json:"name"`
+	Price      *int    `json:"price"`
+	Stock      *int    `json:"stock"`
+	Cost       *int    `json:"cost"`
+	CategoryID *int    `json:"category_id"`
+	Version    *int    `json:"version"`
+}
+
+type ProductFilter struct {
+	Name            string
+	CategoryID      int // termasuk semua sub kategori
+	IncludeArchived bool
 }
@@ -1,12 +1,216 @@
 package models
 
+import "time"
+
 type Report struct {
+	StartDate        string         `json:"start_date"`
+	EndDate          string         `json:"end_date"`
 	TotalRevenue     int            `json:"total_revenue"`
 	TotalTransaction int            `json:"total_transaksi"`
-	ProdukTerlaris   ProdukTerlaris `json:"produk_terlaris"`
+	ProdukTerlaris   ProdukTerlaris `json:"produk_terlaris"` // sama dengan top_by_qty[0], dipertahankan untuk client lama
+	TopByQty         []ProductSales `json:"top_by_qty"`
+	TopByRevenue     []ProductSales `json:"top_by_revenue"`
+	SlowMovers       []ProductSales `json:"slow_movers"` // N produk terjual paling sedikit
+	ZeroSales        []ProductSales `json:"zero_sales"`  // produk aktif yang tidak terjual sama sekali
+	Comparison       *Comparison    `json:"comparison,omitempty"`
+}
+
+// Mode periode pembanding laporan
+const (
+	ComparePrevious = "previous"  // periode dengan panjang sama tepat sebelum periode laporan
+	CompareLastYear = "last_year" // tanggal yang sama tahun lalu
+)
+
+// Comparison metrik periode pembanding beserta selisihnya terhadap periode laporan
+type Comparison struct {
+	Mode             string      `json:"mode"`
+	StartDate        string      `json:"start_date"`
+	EndDate          string      `json:"end_date"`
+	TotalRevenue     MetricDelta `json:"total_revenue"`
+	TotalTransaction MetricDelta `json:"total_transaksi"`
+	AverageBasket    MetricDelta `json:"average_basket"`
+}
+
+// MetricDelta Change = Current - Previous, ChangePct null kalau Previous 0
+type MetricDelta struct {
+	Current   int      `json:"current"`
+	Previous  int      `json:"previous"`
+	Change    int      `json:"change"`
+	ChangePct *float64 `json:"change_pct"`
+}
+
+// RowComparison selisih satu baris laporan (kategori / produk) terhadap periode pembanding
+type RowComparison struct {
+	QtyTerjual MetricDelta `json:"qty_terjual"`
+	Revenue    MetricDelta `json:"revenue"`
+}
+
+// ProductSales penjualan satu produk dalam periode laporan, Rank mulai dari 1
+type ProductSales struct {
+	Rank       int    `json:"rank"`
+	ProductID  int    `json:"product_id"`
+	Nama       string `json:"nama"`
+	QtyTerjual int    `json:"qty_terjual"`
+	Revenue    int    `json:"revenue"`
 }
 
 type ProdukTerlaris struct {
 	Nama       string `json:"nama"`
 	QtyTerjual int    `json:"qty_terjual"`
 }
+
+// CategoryRollup total penjualan satu kategori termasuk semua sub kategorinya
+type CategoryRollup struct {
+	CategoryID   int            `json:"category_id"`
+	Nama         string         `json:"nama"`
+	TotalRevenue int            `json:"total_revenue"`
+	QtyTerjual   int            `json:"qty_terjual"`
+	Comparison   *RowComparison `json:"comparison,omitempty"`
+}
+
+// SalesBucket penjualan dalam satu periode (jam / hari / minggu / bulan), periode tanpa transaksi tetap ada dengan nilai 0
+type SalesBucket struct {
+	Period           time.Time `json:"period"` // awal periode
+	Revenue          int       `json:"revenue"`
+	TransactionCount int       `json:"transaction_count"`
+	ItemsSold        int       `json:"items_sold"`
+	AverageBasket    int       `json:"average_basket"` // revenue / transaction_count
+}
+
+type SalesSeries struct {
+	GroupBy    string            `json:"group_by"`
+	StartDate  string            `json:"start_date"`
+	EndDate    string            `json:"end_date"`
+	Buckets    []SalesBucket     `json:"buckets"`
+	Comparison *SeriesComparison `json:"comparison,omitempty"`
+}
+
+// SeriesComparison tren periode pembanding dengan group_by yang sama, total periode dibandingkan dengan total tren laporan
+type SeriesComparison struct {
+	Comparison
+	ItemsSold MetricDelta   `json:"items_sold"`
+	Buckets   []SalesBucket `json:"buckets"`
+}
+
+// Dimensi breakdown penjualan
+const (
+	BreakdownByCategory = "category"
+	BreakdownByProduct  = "product"
+)
+
+// BreakdownQuery filter, urutan dan halaman breakdown penjualan
+type BreakdownQuery struct {
+	By       string
+	Period   PeriodQuery
+	Compare  string // previous atau last_year, kosong tanpa pembanding
+	OutletID int    // 0 = semua outlet
+	Sort     string // revenue, qty atau name
+	Order    string // asc atau desc
+	Page     int    // mulai dari 1
+	PageSize int
+	All      bool // semua baris tanpa paging, untuk export
+}
+
+// BreakdownRow penjualan satu kategori atau satu produk, share dalam persen dari total periode
+type BreakdownRow struct {
+	ID           int     `json:"id"` // id kategori (0 = tanpa kategori) atau id produk
+	Nama         string  `json:"nama"`
+	CategoryID   int     `json:"category_id,omitempty"`   // khusus breakdown per produk
+	CategoryName string  `json:"category_name,omitempty"` // khusus breakdown per produk
+	QtyTerjual   int     `json:"qty_terjual"`
+	Revenue      int     `json:"revenue"`
+	QtyShare     float64 `json:"qty_share"`
+	RevenueShare float64 `json:"revenue_share"`
+	// Comparison qty dan omzet baris yang sama di periode pembanding
+	Comparison *RowComparison `json:"comparison,omitempty"`
+}
+
+// SalesBreakdown satu halaman breakdown, total dihitung dari semua baris bukan hanya halaman ini
+type SalesBreakdown struct {
+	By           string               `json:"by"`
+	StartDate    string               `json:"start_date"`
+	EndDate      string               `json:"end_date"`
+	Sort         string               `json:"sort"`
+	Order        string               `json:"order"`
+	Page         int                  `json:"page"`
+	PageSize     int                  `json:"page_size"`
+	TotalRows    int                  `json:"total_rows"`
+	TotalQty     int                  `json:"total_qty"`
+	TotalRevenue int                  `json:"total_revenue"` // jumlah subtotal item, sebelum potongan poin
+	Rows         []BreakdownRow       `json:"rows"`
+	Comparison   *BreakdownComparison `json:"comparison,omitempty"`
+}
+
+// BreakdownComparison total periode pembanding, termasuk baris yang tidak terjual di periode laporan
+type BreakdownComparison struct {
+	Mode         string      `json:"mode"`
+	StartDate    string      `json:"start_date"`
+	EndDate      string      `json:"end_date"`
+	TotalQty     MetricDelta `json:"total_qty"`
+	TotalRevenue MetricDelta `json:"total_revenue"`
+}
+
+// PeriodQuery periode laporan dari query string: Range (nama rentang relatif seperti yesterday, last_month, ytd)
+// atau StartDate dan EndDate (YYYY-MM-DD), semua kosong berarti hari ini
+type PeriodQuery struct {
+	Range     string
+	StartDate string
+	EndDate   string
+}
+
+// dimensi laporan persediaan
+const (
+	InventoryByProduct  = "product"
+	InventoryByCategory = "category"
+)
+
+// InventoryQuery filter, urutan dan halaman laporan persediaan
+type InventoryQuery struct {
+	By       string
+	OutletID int    // 0 = stok total semua outlet
+	Days     int    // jendela penjualan untuk kecepatan jual, dalam hari
+	Sort     string // value, stock, cover, velocity, aging atau name
+	Order    string // asc atau desc
+	Page     int    // mulai dari 1
+	PageSize int
+	All      bool // semua baris tanpa paging, untuk export
+}
+
+// InventoryRow persediaan satu produk atau satu kategori. Nilai stok dihitung dari harga jual saat ini,
+// nilai pokok hanya dari produk yang harga pokoknya diketahui
+type InventoryRow struct {
+	ID                  int      `json:"id"` // id produk atau id kategori (0 = tanpa kategori)
+	Nama                string   `json:"nama"`
+	CategoryID          int      `json:"category_id,omitempty"`   // khusus per produk
+	CategoryName        string   `json:"category_name,omitempty"` // khusus per produk
+	ProductCount        int      `json:"product_count,omitempty"` // khusus per kategori
+	Price               int      `json:"price,omitempty"`         // khusus per produk
+	Cost                *int     `json:"cost,omitempty"`          // khusus per produk, kosong kalau belum diketahui
+	Stock               int      `json:"stock"`
+	RetailValue         int      `json:"retail_value"`          // stok x harga jual
+	CostValue           *int     `json:"cost_value"`            // stok x harga pokok, null kalau tidak ada harga pokok sama sekali
+	CostUnknownProducts int      `json:"cost_unknown_products"` // jumlah produk yang belum punya harga pokok
+	QtyTerjual          int      `json:"qty_terjual"`           // dalam jendela penjualan
+	DailyVelocity       float64  `json:"daily_velocity"`        // rata-rata qty terjual per hari
+	DaysOfCover         *float64 `json:"days_of_cover"`         // perkiraan hari sampai stok habis, null kalau tidak ada penjualan
+	LastSoldDate        *string  `json:"last_sold_date"`        // hari bisnis penjualan terakhir (YYYY-MM-DD), tidak dibatasi jendela
+	DaysSinceLastSale   *int     `json:"days_since_last_sale"`  // null kalau belum pernah terjual
+}
+
+// InventoryReport satu halaman laporan persediaan, total dihitung dari semua baris bukan hanya halaman ini
+type InventoryReport struct {
+	By                  string         `json:"by"`
+	Days                int            `json:"days"`
+	WindowStart         string         `json:"window_start"`
+	WindowEnd           string         `json:"window_end"`
+	Sort                string         `json:"sort"`
+	Order               string         `json:"order"`
+	Page                int            `json:"page"`
+	PageSize            int            `json:"page_size"`
+	TotalRows           int            `json:"total_rows"`
+	TotalStock          int            `json:"total_stock"`
+	TotalRetailValue    int            `json:"total_retail_value"`
+	TotalCostValue      int            `json:"total_cost_value"`      // hanya produk yang harga pokoknya diketahui
+	CostUnknownProducts int            `json:"cost_unknown_products"` // produk yang tidak masuk total_cost_value
+	Rows                []InventoryRow `json:"rows"`
+}
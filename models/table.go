@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// DiningTable meja untuk order dine-in
+type DiningTable struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Seats       int        `json:"seats"`
+	OpenOrderID *int       `json:"open_order_id,omitempty"` // order draft / parkir yang sedang berjalan di meja ini
+	Archived    bool       `json:"archived"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -3,10 +3,18 @@ package models
 import "time"
 
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID             int                 `json:"id"`
+	TotalAmount    int                 `json:"total_amount"`
+	PriceList      string              `json:"price_list"`
+	CustomerID     *int                `json:"customer_id,omitempty"`
+	OrderID        *int                `json:"order_id,omitempty"`
+	OutletID       int                 `json:"outlet_id"`
+	PointsRedeemed int                 `json:"points_redeemed"`
+	PointsEarned   int                 `json:"points_earned"`
+	AmountDue      int                 `json:"amount_due"` // total dikurangi nilai poin yang ditukar
+	PaymentMethod  string              `json:"payment_method"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Details        []TransactionDetail `json:"details"`
 }
 
 type TransactionDetail struct {
@@ -15,6 +23,8 @@ type TransactionDetail struct {
 	ProductID     int    `json:"product_id"`
 	ProductName   string `json:"product_name,omitempty"`
 	Quantity      int    `json:"quantity"`
+	UnitPrice     int    `json:"unit_price"`
+	PriceList     string `json:"price_list"`
 	Subtotal      int    `json:"subtotal"`
 }
 
@@ -24,5 +34,22 @@ type CheckoutItem struct {
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	Items         []CheckoutItem `json:"items"`
+	PriceList     string         `json:"price_list"` // kode daftar harga, default dari customer atau retail
+	CustomerID    int            `json:"customer_id,omitempty"`
+	RedeemPoints  int            `json:"redeem_points,omitempty"`
+	OutletID      int            `json:"outlet_id,omitempty"`      // default outlet utama
+	PaymentMethod string         `json:"payment_method,omitempty"` // cash, card, qris atau transfer, default cash
+	OrderID       int            `json:"-"`                        // diisi saat checkout dari order
 }
+
+// Metode pembayaran transaksi
+const (
+	PaymentCash     = "cash"
+	PaymentCard     = "card"
+	PaymentQRIS     = "qris"
+	PaymentTransfer = "transfer"
+)
+
+// PaymentMethods urutan metode pembayaran yang valid
+var PaymentMethods = []string{PaymentCash, PaymentCard, PaymentQRIS, PaymentTransfer}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Status transfer stok
+const (
+	TransferDraft     = "draft"
+	TransferShipped   = "shipped"
+	TransferReceived  = "received"
+	TransferCancelled = "cancelled"
+)
+
+// Penanganan kekurangan saat transfer diterima
+const (
+	ShortageReturned   = "returned"    // kembali ke stok outlet asal
+	ShortageWrittenOff = "written_off" // hilang / rusak di jalan, dicatat di stock_write_off
+
+	WriteOffTransferShortage = "transfer_shortage"
+)
+
+// StockTransfer dokumen pindah stok antar outlet. Stok asal berkurang saat dikirim,
+// stok tujuan bertambah sebanyak yang benar-benar diterima
+type StockTransfer struct {
+	ID             int                 `json:"id"`
+	FromOutletID   int                 `json:"from_outlet_id"`
+	FromOutletName string              `json:"from_outlet_name,omitempty"`
+	ToOutletID     int                 `json:"to_outlet_id"`
+	ToOutletName   string              `json:"to_outlet_name,omitempty"`
+	Status         string              `json:"status"`
+	Note           string              `json:"note"`
+	ReceiveNote    string              `json:"receive_note,omitempty"`
+	HasDiscrepancy bool                `json:"has_discrepancy"`
+	ShortageAction string              `json:"shortage_action,omitempty"` // returned / written_off, kosong kalau tidak ada kekurangan
+	Items          []StockTransferItem `json:"items"`
+	CreatedAt      time.Time           `json:"created_at"`
+	ShippedAt      *time.Time          `json:"shipped_at,omitempty"`
+	ReceivedAt     *time.Time          `json:"received_at,omitempty"`
+}
+
+type StockTransferItem struct {
+	ID               int    `json:"id"`
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name,omitempty"`
+	Quantity         int    `json:"quantity"`
+	ReceivedQuantity *int   `json:"received_quantity,omitempty"`
+	// Discrepancy diterima dikurangi dikirim, negatif berarti barang kurang / rusak di jalan
+	Discrepancy int `json:"discrepancy"`
+}
+
+type StockTransferRequest struct {
+	FromOutletID int            `json:"from_outlet_id"`
+	ToOutletID   int            `json:"to_outlet_id"`
+	Note         string         `json:"note"`
+	Items        []CheckoutItem `json:"items"`
+}
+
+// ReceiveTransferRequest produk yang tidak disebut dianggap diterima sesuai qty kirim.
+// Kekurangan di-write-off, kecuali ReturnShortage yang mengembalikannya ke outlet asal
+type ReceiveTransferRequest struct {
+	Items          []ReceivedItem `json:"items"`
+	Note           string         `json:"note"`
+	ReturnShortage bool           `json:"return_shortage"`
+}
+
+type ReceivedItem struct {
+	ProductID        int `json:"product_id"`
+	ReceivedQuantity int `json:"received_quantity"`
+}
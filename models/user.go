@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Role user
+const (
+	UserRoleAdmin   = "admin"
+	UserRoleCashier = "cashier"
+)
+
+// User pengguna API, user dengan OutletID hanya bisa bertransaksi dan melihat data outletnya sendiri
+type User struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	OutletID  *int      `json:"outlet_id,omitempty"`
+	Token     string    `json:"token,omitempty"` // hanya dikirim sekali saat user dibuat
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// ZReport laporan tutup hari (end-of-day) satu outlet. Disimpan permanen dan tidak bisa diubah,
+// setelah hari bisnis ditutup outlet tidak bisa checkout lagi sampai hari berikutnya
+type ZReport struct {
+	ID           int       `json:"id"`
+	Sequence     int       `json:"sequence"` // nomor urut Z per outlet, mulai dari 1
+	OutletID     int       `json:"outlet_id"`
+	OutletName   string    `json:"outlet_name"`
+	BusinessDate string    `json:"business_date"` // YYYY-MM-DD di zona waktu toko
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+
+	TransactionCount int `json:"transaction_count"`
+	ItemsSold        int `json:"items_sold"`
+	GrossSales       int `json:"gross_sales"`     // jumlah total_amount
+	PointsRedeemed   int `json:"points_redeemed"` // jumlah poin yang ditukar
+	PointsDiscount   int `json:"points_discount"` // nilai rupiah poin yang ditukar, gross - net
+	NetSales         int `json:"net_sales"`       // jumlah amount_due, yang benar-benar dibayar
+	AverageBasket    int `json:"average_basket"`  // gross_sales / transaction_count
+
+	Payments           []ZReportPayment `json:"payments"` // per metode pembayaran
+	FirstTransactionID *int             `json:"first_transaction_id,omitempty"`
+	LastTransactionID  *int             `json:"last_transaction_id,omitempty"`
+
+	Note     string    `json:"note"`
+	ClosedBy *int      `json:"closed_by,omitempty"` // user yang menutup hari, kosong kalau tanpa login
+	ClosedAt time.Time `json:"closed_at"`
+}
+
+type ZReportPayment struct {
+	Method           string `json:"method"`
+	TransactionCount int    `json:"transaction_count"`
+	Amount           int    `json:"amount"` // jumlah amount_due
+}
+
+type ZReportRequest struct {
+	OutletID     int    `json:"outlet_id,omitempty"`     // default outlet utama
+	BusinessDate string `json:"business_date,omitempty"` // YYYY-MM-DD, default hari ini
+	Note         string `json:"note"`
+}
@@ -2,10 +2,23 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
+	"kasir-api/apperrors"
 	"kasir-api/models"
+	"time"
 )
 
+// categorySubtree subquery id kategori beserta semua turunannya,
+// param adalah placeholder id kategori root (misal "$1")
+func categorySubtree(param string) string {
+	return `WITH RECURSIVE subtree AS (
+			SELECT id FROM category WHERE id = ` + param + `
+			UNION
+			SELECT c.id FROM category c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree`
+}
+
 type CategoryRepository struct {
 	db *sql.DB
 }
@@ -14,8 +27,12 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
-func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
-	query := "SELECT id, name, COALESCE(description, '') FROM category"
+func (repo *CategoryRepository) GetAll(includeArchived bool) ([]models.Category, error) {
+	query := "SELECT id, name, COALESCE(description, ''), parent_id, archived, archived_at FROM category"
+	if !includeArchived {
+		query += " WHERE archived = FALSE"
+	}
+	query += " ORDER BY name"
 	rows, err := repo.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -25,7 +42,7 @@ func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 	categories := make([]models.Category, 0)
 	for rows.Next() {
 		var c models.Category
-		err := rows.Scan(&c.ID, &c.Name, &c.Description)
+		err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.ParentID, &c.Archived, &c.ArchivedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -35,19 +52,19 @@ func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 }
 
 func (repo *CategoryRepository) Create(data *models.Category) error {
-	query := "INSERT INTO category (name, description) VALUES ($1, $2) RETURNING id"
-	err := repo.db.QueryRow(query, data.Name, data.Description).Scan(&data.ID)
+	query := "INSERT INTO category (name, description, parent_id) VALUES ($1, $2, $3) RETURNING id"
+	err := repo.db.QueryRow(query, data.Name, data.Description, data.ParentID).Scan(&data.ID)
 	return err
 }
 
 // Category GetByID
 func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
-	query := "SELECT id, name, COALESCE(description, '') FROM category WHERE id = $1"
+	query := "SELECT id, name, COALESCE(description, ''), parent_id, archived, archived_at FROM category WHERE id = $1"
 
 	var c models.Category
-	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description)
+	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description, &c.ParentID, &c.Archived, &c.ArchivedAt)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("Category tidak ditemukan")
+		return nil, apperrors.NotFound("Kategori tidak ditemukan")
 	}
 	if err != nil {
 		return nil, err
@@ -57,9 +74,9 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 
 // Update Category
 func (repo *CategoryRepository) Update(category *models.Category) error {
-	query := "UPDATE category SET name = $1, description = $2 WHERE id = $3"
+	query := "UPDATE category SET name = $1, description = $2, parent_id = $3 WHERE id = $4"
 
-	result, err := repo.db.Exec(query, category.Name, category.Description, category.ID)
+	result, err := repo.db.Exec(query, category.Name, category.Description, category.ParentID, category.ID)
 	if err != nil {
 		return err
 	}
@@ -70,15 +87,126 @@ func (repo *CategoryRepository) Update(category *models.Category) error {
 	}
 
 	if rows == 0 {
-		return errors.New("Category tidak ditemukan")
+		return apperrors.NotFound("Kategori tidak ditemukan")
 	}
 
 	return nil
 }
 
-// Delete Category
-func (repo *CategoryRepository) Delete(id int) error {
-	query := "DELETE FROM category WHERE id = $1"
+// Delete Category (soft delete)
+// Kalau masih ada produk, moveTo > 0 memindahkan produk ke kategori lain
+// dan uncategorize melepas kategori dari produk. Tanpa keduanya delete ditolak.
+func (repo *CategoryRepository) Delete(id int, moveTo int, uncategorize bool) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("SELECT id FROM category WHERE id = $1 AND archived = FALSE FOR UPDATE", id).Scan(&id)
+	if err == sql.ErrNoRows {
+		return apperrors.NotFound("Kategori tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+
+	var childCount int
+	err = tx.QueryRow("SELECT COUNT(*) FROM category WHERE parent_id = $1 AND archived = FALSE", id).Scan(&childCount)
+	if err != nil {
+		return err
+	}
+	if childCount > 0 {
+		return apperrors.Conflict(fmt.Sprintf("Kategori masih memiliki %d sub kategori, hapus atau pindahkan dulu", childCount))
+	}
+
+	var productCount int
+	err = tx.QueryRow("SELECT COUNT(*) FROM product WHERE category_id = $1", id).Scan(&productCount)
+	if err != nil {
+		return err
+	}
+
+	if productCount > 0 {
+		switch {
+		case moveTo > 0:
+			if moveTo == id {
+				return apperrors.Validation(apperrors.CodeInvalid, "move_to", "Kategori tujuan tidak boleh sama dengan kategori yang dihapus")
+			}
+
+			var exists bool
+			err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM category WHERE id = $1 AND archived = FALSE)", moveTo).Scan(&exists)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return apperrors.Validation(apperrors.CodeInvalidReference, "move_to", "Kategori tujuan tidak ditemukan")
+			}
+
+			_, err = tx.Exec("UPDATE product SET category_id = $1 WHERE category_id = $2", moveTo, id)
+			if err != nil {
+				return err
+			}
+		case uncategorize:
+			_, err = tx.Exec("UPDATE product SET category_id = NULL WHERE category_id = $1", id)
+			if err != nil {
+				return err
+			}
+		default:
+			return apperrors.Conflict(fmt.Sprintf("Kategori masih dipakai oleh %d produk, gunakan move_to atau uncategorize=true", productCount))
+		}
+	}
+
+	_, err = tx.Exec("UPDATE category SET archived = TRUE, archived_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsDescendant cek apakah candidateID adalah turunan (atau sama dengan) kategori id
+func (repo *CategoryRepository) IsDescendant(id, candidateID int) (bool, error) {
+	query := "SELECT EXISTS(SELECT 1 FROM (" + categorySubtree("$1") + ") t WHERE t.id = $2)"
+
+	var exists bool
+	err := repo.db.QueryRow(query, id, candidateID).Scan(&exists)
+	return exists, err
+}
+
+// GetStats statistik stok kategori beserta sub kategorinya dan penjualan dalam [start, end).
+// outletID > 0 pakai stok dan penjualan outlet tersebut saja, 0 total semua outlet
+func (repo *CategoryRepository) GetStats(id int, start, end time.Time, outletID int) (*models.CategoryStats, error) {
+	var stats models.CategoryStats
+
+	err := repo.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN $2 = 0 THEN p.stock ELSE COALESCE(os.stock, 0) END), 0),
+		       COALESCE(SUM(CASE WHEN $2 = 0 THEN p.stock ELSE COALESCE(os.stock, 0) END * p.price), 0)
+		FROM product p
+		LEFT JOIN outlet_stock os ON os.product_id = p.id AND os.outlet_id = $2
+		WHERE p.archived = FALSE AND p.category_id IN (`+categorySubtree("$1")+`)
+	`, id, outletID).Scan(&stats.ProductCount, &stats.TotalStock, &stats.TotalStockValue)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.db.QueryRow(`
+		SELECT COALESCE(SUM(td.subtotal), 0), COUNT(DISTINCT t.id), COALESCE(SUM(td.quantity), 0)
+		FROM transaction_details td
+		JOIN product p ON td.product_id = p.id
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE t.created_at >= $2 AND t.created_at < $3 AND ($4 = 0 OR t.outlet_id = $4)
+		AND p.category_id IN (`+categorySubtree("$1")+`)
+	`, id, start, end, outletID).Scan(&stats.TotalRevenue, &stats.TotalTransaction, &stats.QtyTerjual)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// Restore Category yang sudah di-archive
+func (repo *CategoryRepository) Restore(id int) error {
+	query := "UPDATE category SET archived = FALSE, archived_at = NULL WHERE id = $1 AND archived = TRUE"
 	result, err := repo.db.Exec(query, id)
 	if err != nil {
 		return err
@@ -88,7 +216,7 @@ func (repo *CategoryRepository) Delete(id int) error {
 		return err
 	}
 	if rows == 0 {
-		return errors.New("Kategori tidak ditemukan")
+		return apperrors.NotFound("Kategori archived tidak ditemukan")
 	}
 	return nil
 }
@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+const customerColumns = `c.id, c.name, COALESCE(c.phone, ''), COALESCE(c.email, ''), COALESCE(pl.code, ''), c.points_balance, c.created_at`
+
+func scanCustomer(row interface{ Scan(...interface{}) error }, c *models.Customer) error {
+	return row.Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.PriceList, &c.PointsBalance, &c.CreatedAt)
+}
+
+// GetAll search mencari di nama, telepon dan email
+func (repo *CustomerRepository) GetAll(search string) ([]models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customer c LEFT JOIN price_list pl ON c.price_list_id = pl.id"
+	args := []interface{}{}
+	if search != "" {
+		query += " WHERE c.name ILIKE $1 OR c.phone ILIKE $1 OR c.email ILIKE $1"
+		args = append(args, "%"+search+"%")
+	}
+	query += " ORDER BY c.name"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]models.Customer, 0)
+	for rows.Next() {
+		var c models.Customer
+		err := scanCustomer(rows, &c)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+	return customers, nil
+}
+
+func (repo *CustomerRepository) GetByID(id int) (*models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customer c LEFT JOIN price_list pl ON c.price_list_id = pl.id WHERE c.id = $1"
+
+	var c models.Customer
+	err := scanCustomer(repo.db.QueryRow(query, id), &c)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Customer tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// priceListID cari id daftar harga dari kode, kode kosong berarti tanpa daftar harga
+func (repo *CustomerRepository) priceListID(code string) (*int, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	var id int
+	err := repo.db.QueryRow("SELECT id FROM price_list WHERE code = $1", code).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.Validation(apperrors.CodeInvalidReference, "price_list", fmt.Sprintf("Daftar harga %s tidak ditemukan", code))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (repo *CustomerRepository) Create(customer *models.Customer) error {
+	priceListID, err := repo.priceListID(customer.PriceList)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO customer (name, phone, email, price_list_id)
+        VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4)
+        RETURNING id, points_balance, created_at`
+	return repo.db.QueryRow(query, customer.Name, customer.Phone, customer.Email, priceListID).
+		Scan(&customer.ID, &customer.PointsBalance, &customer.CreatedAt)
+}
+
+// Update data customer, saldo poin hanya berubah lewat checkout
+func (repo *CustomerRepository) Update(customer *models.Customer) error {
+	priceListID, err := repo.priceListID(customer.PriceList)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE customer SET name = $1, phone = NULLIF($2, ''), email = NULLIF($3, ''), price_list_id = $4
+        WHERE id = $5
+        RETURNING points_balance, created_at`
+	err = repo.db.QueryRow(query, customer.Name, customer.Phone, customer.Email, priceListID, customer.ID).
+		Scan(&customer.PointsBalance, &customer.CreatedAt)
+	if err == sql.ErrNoRows {
+		return apperrors.NotFound("Customer tidak ditemukan")
+	}
+	return err
+}
+
+// Delete customer hanya kalau belum punya transaksi maupun histori poin
+func (repo *CustomerRepository) Delete(id int) error {
+	var hasHistory bool
+	err := repo.db.QueryRow(`
+        SELECT EXISTS(SELECT 1 FROM transactions WHERE customer_id = $1)
+            OR EXISTS(SELECT 1 FROM loyalty_ledger WHERE customer_id = $1)`, id).Scan(&hasHistory)
+	if err != nil {
+		return err
+	}
+	if hasHistory {
+		return apperrors.Conflict("Customer sudah punya transaksi, tidak bisa dihapus")
+	}
+
+	var hasOrders bool
+	err = repo.db.QueryRow("SELECT EXISTS(SELECT 1 FROM orders WHERE customer_id = $1)", id).Scan(&hasOrders)
+	if err != nil {
+		return err
+	}
+	if hasOrders {
+		return apperrors.Conflict("Customer masih dipakai di order, tidak bisa dihapus")
+	}
+
+	result, err := repo.db.Exec("DELETE FROM customer WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperrors.NotFound("Customer tidak ditemukan")
+	}
+	return nil
+}
+
+// GetLedger histori poin customer, terbaru di atas
+func (repo *CustomerRepository) GetLedger(customerID int) ([]models.LoyaltyEntry, error) {
+	query := `
+        SELECT id, customer_id, transaction_id, points, reason, balance_after, created_at
+        FROM loyalty_ledger
+        WHERE customer_id = $1
+        ORDER BY created_at DESC, id DESC`
+	rows, err := repo.db.Query(query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.LoyaltyEntry, 0)
+	for rows.Next() {
+		var e models.LoyaltyEntry
+		err := rows.Scan(&e.ID, &e.CustomerID, &e.TransactionID, &e.Points, &e.Reason, &e.BalanceAfter, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
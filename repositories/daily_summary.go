@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+	"sort"
+	"time"
+)
+
+// recordDailySalesTx tambahkan transaksi ke ringkasan harian di hari bisnis (zona waktu toko) saat transaksi dibuat.
+// Baris ringkasan diupdate urut product_id, sama dengan urutan kunci produk di createTransactionTx.
+// Belum ada pembalikan untuk refund, kalau refund ditambahkan ringkasan harus dikurangi di transaksi yang sama
+func recordDailySalesTx(tx *sql.Tx, t *models.Transaction, loc *time.Location) error {
+	businessDate := t.CreatedAt.In(loc).Format("2006-01-02")
+
+	qty := make(map[int]int)
+	revenue := make(map[int]int)
+	productIDs := make([]int, 0, len(t.Details))
+	itemsSold := 0
+	for _, d := range t.Details {
+		if _, ok := qty[d.ProductID]; !ok {
+			productIDs = append(productIDs, d.ProductID)
+		}
+		qty[d.ProductID] += d.Quantity
+		revenue[d.ProductID] += d.Subtotal
+		itemsSold += d.Quantity
+	}
+	sort.Ints(productIDs)
+
+	_, err := tx.Exec(`
+		INSERT INTO daily_sales_totals (business_date, outlet_id, transaction_count, revenue, items_sold)
+		VALUES ($1, $2, 1, $3, $4)
+		ON CONFLICT (business_date, outlet_id) DO UPDATE SET
+			transaction_count = daily_sales_totals.transaction_count + 1,
+			revenue = daily_sales_totals.revenue + EXCLUDED.revenue,
+			items_sold = daily_sales_totals.items_sold + EXCLUDED.items_sold
+	`, businessDate, t.OutletID, t.TotalAmount, itemsSold)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range productIDs {
+		_, err = tx.Exec(`
+			INSERT INTO daily_sales_summary (business_date, outlet_id, product_id, qty, revenue)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (business_date, outlet_id, product_id) DO UPDATE SET
+				qty = daily_sales_summary.qty + EXCLUDED.qty,
+				revenue = daily_sales_summary.revenue + EXCLUDED.revenue
+		`, businessDate, t.OutletID, id, qty[id], revenue[id])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetFirstSaleAt waktu transaksi paling awal, nil kalau belum ada transaksi
+func (r *ReportRepository) GetFirstSaleAt() (*time.Time, error) {
+	var first *time.Time
+	err := r.db.QueryRow("SELECT MIN(created_at) FROM transactions").Scan(&first)
+	return first, err
+}
+
+// IsDailySummaryEmpty true kalau ringkasan harian belum pernah diisi
+func (r *ReportRepository) IsDailySummaryEmpty() (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM daily_sales_totals)").Scan(&exists)
+	return !exists, err
+}
+
+// RebuildDailySummary hitung ulang ringkasan harian untuk hari bisnis dalam [start, end) dari transaksi,
+// start dan end harus tengah malam zona waktu toko. Tabel ringkasan dikunci selama proses supaya
+// checkout yang berjalan bersamaan tidak hilang atau terhitung dua kali. Mengembalikan jumlah transaksi
+func (r *ReportRepository) RebuildDailySummary(start, end time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("LOCK TABLE daily_sales_totals, daily_sales_summary IN EXCLUSIVE MODE")
+	if err != nil {
+		return 0, err
+	}
+
+	startDate := start.In(r.loc).Format("2006-01-02")
+	endDate := end.In(r.loc).Format("2006-01-02")
+	for _, table := range []string{"daily_sales_totals", "daily_sales_summary"} {
+		_, err = tx.Exec("DELETE FROM "+table+" WHERE business_date >= $1::date AND business_date < $2::date", startDate, endDate)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tz := r.loc.String()
+	var count int
+	err = tx.QueryRow(`
+		WITH inserted AS (
+			INSERT INTO daily_sales_totals (business_date, outlet_id, transaction_count, revenue, items_sold)
+			SELECT (t.created_at AT TIME ZONE $3)::date, t.outlet_id, COUNT(*), SUM(t.total_amount),
+			       COALESCE(SUM((SELECT SUM(td.quantity) FROM transaction_details td WHERE td.transaction_id = t.id)), 0)
+			FROM transactions t
+			WHERE t.created_at >= $1 AND t.created_at < $2
+			GROUP BY 1, 2
+			RETURNING transaction_count
+		)
+		SELECT COALESCE(SUM(transaction_count), 0) FROM inserted
+	`, start, end, tz).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO daily_sales_summary (business_date, outlet_id, product_id, qty, revenue)
+		SELECT (t.created_at AT TIME ZONE $3)::date, t.outlet_id, td.product_id, SUM(td.quantity), SUM(td.subtotal)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE t.created_at >= $1 AND t.created_at < $2
+		GROUP BY 1, 2, 3
+	`, start, end, tz)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
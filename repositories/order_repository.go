@@ -0,0 +1,921 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type OrderRepository struct {
+	db  *sql.DB
+	loc *time.Location // zona waktu toko untuk ringkasan penjualan harian
+}
+
+func NewOrderRepository(db *sql.DB, loc *time.Location) *OrderRepository {
+	return &OrderRepository{db: db, loc: loc}
+}
+
+const orderSelect = `
+	SELECT o.id, o.status, o.customer_id, o.table_id, COALESCE(dt.name, ''), COALESCE(o.outlet_id, 0), o.price_list, o.note,
+	       o.stock_reserved, o.transaction_id, o.created_at, o.updated_at
+	FROM orders o
+	LEFT JOIN dining_tables dt ON o.table_id = dt.id`
+
+func scanOrder(row interface{ Scan(...interface{}) error }, o *models.Order) error {
+	return row.Scan(&o.ID, &o.Status, &o.CustomerID, &o.TableID, &o.TableName, &o.OutletID, &o.PriceList, &o.Note,
+		&o.StockReserved, &o.TransactionID, &o.CreatedAt, &o.UpdatedAt)
+}
+
+// Create buat order draft baru di outlet req.OutletID (0 = outlet default),
+// reserve menentukan apakah stok item langsung ditahan
+func (repo *OrderRepository) Create(req models.OrderRequest, reserve bool) (*models.Order, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var customerID, tableID *int
+	if req.CustomerID > 0 {
+		customerID = &req.CustomerID
+	}
+	if req.TableID > 0 {
+		err = lockTableForOrder(tx, req.TableID)
+		if err != nil {
+			return nil, err
+		}
+		tableID = &req.TableID
+	}
+
+	outletID, err := resolveOutletTx(tx, req.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	order := models.Order{OutletID: outletID, StockReserved: reserve}
+	err = tx.QueryRow(`
+		INSERT INTO orders (status, customer_id, table_id, outlet_id, price_list, note, stock_reserved)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
+	`, models.OrderDraft, customerID, tableID, outletID, req.PriceList, req.Note, reserve).Scan(&order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		err = addOrderItemTx(tx, &order, item.ProductID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(order.ID)
+}
+
+// lockTableForOrder meja harus aktif dan belum punya order terbuka
+func lockTableForOrder(tx *sql.Tx, tableID int) error {
+	var name string
+	var archived bool
+	err := tx.QueryRow("SELECT name, archived FROM dining_tables WHERE id = $1 FOR UPDATE", tableID).Scan(&name, &archived)
+	if err == sql.ErrNoRows || (err == nil && archived) {
+		return apperrors.Validation(apperrors.CodeInvalidReference, "table_id", fmt.Sprintf("table id %d not found", tableID))
+	}
+	if err != nil {
+		return err
+	}
+
+	var openOrderID int
+	err = tx.QueryRow("SELECT id FROM orders WHERE table_id = $1 AND status IN ($2, $3)",
+		tableID, models.OrderDraft, models.OrderParked).Scan(&openOrderID)
+	if err == nil {
+		conflict := apperrors.Conflict(fmt.Sprintf("Meja %s masih dipakai order #%d", name, openOrderID))
+		conflict.Field = "table_id"
+		return conflict
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+// GetAll daftar order, status kosong berarti semua status, tableID / outletID 0 berarti semua
+func (repo *OrderRepository) GetAll(status string, tableID, outletID int) ([]models.Order, error) {
+	query := orderSelect
+	conditions := []string{}
+	args := []interface{}{}
+	if status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("o.status = $%d", len(args)))
+	}
+	if tableID > 0 {
+		args = append(args, tableID)
+		conditions = append(conditions, fmt.Sprintf("o.table_id = $%d", len(args)))
+	}
+	if outletID > 0 {
+		args = append(args, outletID)
+		conditions = append(conditions, fmt.Sprintf("o.outlet_id = $%d", len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY o.updated_at DESC, o.id DESC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.Order, 0)
+	for rows.Next() {
+		var o models.Order
+		err := scanOrder(rows, &o)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = repo.loadItems(orders)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (repo *OrderRepository) GetByID(id int) (*models.Order, error) {
+	var o models.Order
+	err := scanOrder(repo.db.QueryRow(orderSelect+" WHERE o.id = $1", id), &o)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Order tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []models.Order{o}
+	err = repo.loadItems(orders)
+	if err != nil {
+		return nil, err
+	}
+	return &orders[0], nil
+}
+
+// loadItems isi item dan estimasi total untuk semua order sekaligus
+func (repo *OrderRepository) loadItems(orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(orders))
+	index := make(map[int]int, len(orders))
+	for i := range orders {
+		ids[i] = int64(orders[i].ID)
+		index[orders[i].ID] = i
+		orders[i].Items = make([]models.OrderItem, 0)
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT oi.id, oi.order_id, oi.product_id, p.name, oi.quantity, p.price, oi.ticket_id
+		FROM order_items oi
+		JOIN product p ON oi.product_id = p.id
+		WHERE oi.order_id = ANY($1)
+		ORDER BY oi.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.OrderItem
+		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.ProductName, &item.Quantity, &item.Price, &item.TicketID)
+		if err != nil {
+			return err
+		}
+		item.Subtotal = item.Price * item.Quantity
+
+		o := &orders[index[item.OrderID]]
+		o.Items = append(o.Items, item)
+		o.EstimatedTotal += item.Subtotal
+	}
+	return rows.Err()
+}
+
+// lockOrder kunci baris order selama transaksi database
+func lockOrder(tx *sql.Tx, id int) (*models.Order, error) {
+	var o models.Order
+	err := scanOrder(tx.QueryRow(orderSelect+" WHERE o.id = $1 FOR UPDATE OF o", id), &o)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Order tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// lockEditableOrder item hanya boleh diubah selama order masih draft
+func lockEditableOrder(tx *sql.Tx, id int) (*models.Order, error) {
+	order, err := lockOrder(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderDraft {
+		return nil, apperrors.Conflict(fmt.Sprintf("Order berstatus %s, item hanya bisa diubah saat draft", order.Status))
+	}
+	return order, nil
+}
+
+func touchOrder(tx *sql.Tx, id int) error {
+	_, err := tx.Exec("UPDATE orders SET updated_at = NOW() WHERE id = $1", id)
+	return err
+}
+
+// addOrderItemTx tambah qty ke baris produk yang belum dikirim ke dapur, atau buat baris baru
+func addOrderItemTx(tx *sql.Tx, order *models.Order, productID, quantity int) error {
+	var archived bool
+	err := tx.QueryRow("SELECT archived FROM product WHERE id = $1", productID).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return apperrors.NotFound(fmt.Sprintf("product id %d not found", productID))
+	}
+	if err != nil {
+		return err
+	}
+	if archived {
+		return apperrors.Validation(apperrors.CodeInvalidReference, "product_id", fmt.Sprintf("product id %d is archived", productID))
+	}
+
+	if order.StockReserved {
+		err = reserveStock(tx, order.OutletID, productID, quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	var itemID int
+	err = tx.QueryRow(`
+		UPDATE order_items SET quantity = quantity + $1
+		WHERE id = (
+			SELECT id FROM order_items
+			WHERE order_id = $2 AND product_id = $3 AND ticket_id IS NULL
+			ORDER BY id LIMIT 1
+		)
+		RETURNING id
+	`, quantity, order.ID, productID).Scan(&itemID)
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec("INSERT INTO order_items (order_id, product_id, quantity) VALUES ($1, $2, $3)", order.ID, productID, quantity)
+	}
+	return err
+}
+
+func (repo *OrderRepository) AddItem(orderID, productID, quantity int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockEditableOrder(tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	err = addOrderItemTx(tx, order, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	err = touchOrder(tx, orderID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockOrderItem ambil item milik order untuk diubah, item yang sudah dikirim ke dapur dikunci
+func lockOrderItem(tx *sql.Tx, orderID, itemID int) (productID, quantity int, err error) {
+	var ticketID *int
+	err = tx.QueryRow("SELECT product_id, quantity, ticket_id FROM order_items WHERE id = $1 AND order_id = $2 FOR UPDATE", itemID, orderID).
+		Scan(&productID, &quantity, &ticketID)
+	if err == sql.ErrNoRows {
+		return 0, 0, apperrors.NotFound("Item order tidak ditemukan")
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if ticketID != nil {
+		return 0, 0, apperrors.Conflict("Item sudah dikirim ke dapur dan tidak bisa diubah")
+	}
+	return productID, quantity, nil
+}
+
+// UpdateItem ubah qty item, selisihnya ikut di-reserve / dilepas kalau order menahan stok
+func (repo *OrderRepository) UpdateItem(orderID, itemID, quantity int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockEditableOrder(tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	productID, oldQuantity, err := lockOrderItem(tx, orderID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if order.StockReserved {
+		delta := quantity - oldQuantity
+		if delta > 0 {
+			err = reserveStock(tx, order.OutletID, productID, delta)
+		} else if delta < 0 {
+			err = releaseStock(tx, order.OutletID, productID, -delta)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE order_items SET quantity = $1 WHERE id = $2", quantity, itemID)
+	if err != nil {
+		return err
+	}
+
+	err = touchOrder(tx, orderID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (repo *OrderRepository) RemoveItem(orderID, itemID int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockEditableOrder(tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	productID, quantity, err := lockOrderItem(tx, orderID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if order.StockReserved {
+		err = releaseStock(tx, order.OutletID, productID, quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM order_items WHERE id = $1", itemID)
+	if err != nil {
+		return err
+	}
+
+	err = touchOrder(tx, orderID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ChangeStatus pindah status order, hanya dari status yang diizinkan (park / resume)
+func (repo *OrderRepository) ChangeStatus(id int, from string, to string) error {
+	result, err := repo.db.Exec("UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3", to, id, from)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	order, err := repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return apperrors.Conflict(fmt.Sprintf("Order berstatus %s, tidak bisa diubah ke %s", order.Status, to))
+}
+
+// orderItemsTx item order (id, produk, qty) tanpa data produk
+func orderItemsTx(tx *sql.Tx, orderID int) ([]models.OrderItem, error) {
+	rows, err := tx.Query("SELECT id, product_id, quantity FROM order_items WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.OrderItem, 0)
+	for rows.Next() {
+		item := models.OrderItem{OrderID: orderID}
+		err := rows.Scan(&item.ID, &item.ProductID, &item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// releaseOrderStockTx lepas semua stok yang ditahan order
+func releaseOrderStockTx(tx *sql.Tx, order *models.Order, items []models.OrderItem) error {
+	if !order.StockReserved {
+		return nil
+	}
+	for _, item := range items {
+		err := releaseStock(tx, order.OutletID, item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancel batalkan order yang belum di-checkout dan lepas stok yang ditahan
+func (repo *OrderRepository) Cancel(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockOrder(tx, id)
+	if err != nil {
+		return err
+	}
+	if order.Status != models.OrderDraft && order.Status != models.OrderParked {
+		return apperrors.Conflict(fmt.Sprintf("Order berstatus %s tidak bisa dibatalkan", order.Status))
+	}
+
+	items, err := orderItemsTx(tx, id)
+	if err != nil {
+		return err
+	}
+	err = releaseOrderStockTx(tx, order, items)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2", models.OrderCancelled, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockOrderForSettlementTx kunci order yang siap dibayar (checkout biasa maupun split bill)
+// dan lepas stok yang ditahan, nanti stok dipotong oleh logic checkout biasa
+func lockOrderForSettlementTx(tx *sql.Tx, id int) (*models.Order, []models.OrderItem, error) {
+	order, err := lockOrder(tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.Status != models.OrderDraft && order.Status != models.OrderParked {
+		return nil, nil, apperrors.Conflict(fmt.Sprintf("Order berstatus %s tidak bisa di-checkout", order.Status))
+	}
+
+	items, err := orderItemsTx(tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil, apperrors.Validation(apperrors.CodeRequired, "items", "Order belum punya item")
+	}
+
+	// order meja hanya bisa dibayar setelah semua item masuk dapur
+	if order.TableID != nil {
+		var pending int
+		err = tx.QueryRow("SELECT COUNT(*) FROM order_items WHERE order_id = $1 AND ticket_id IS NULL", id).Scan(&pending)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pending > 0 {
+			return nil, nil, apperrors.Conflict(fmt.Sprintf("Masih ada %d item yang belum dikirim ke dapur", pending))
+		}
+	}
+
+	err = releaseOrderStockTx(tx, order, items)
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, items, nil
+}
+
+// orderCheckoutRequest request checkout biasa untuk sebagian / semua item order
+func orderCheckoutRequest(order *models.Order, items []models.CheckoutItem, customerID, redeemPoints int, paymentMethod string) models.CheckoutRequest {
+	req := models.CheckoutRequest{
+		Items:         items,
+		PriceList:     order.PriceList,
+		CustomerID:    customerID,
+		RedeemPoints:  redeemPoints,
+		OutletID:      order.OutletID,
+		PaymentMethod: paymentMethod,
+		OrderID:       order.ID,
+	}
+	if req.CustomerID == 0 && order.CustomerID != nil {
+		req.CustomerID = *order.CustomerID
+	}
+	return req
+}
+
+// closeOrderTx tandai order selesai, transactionID nil kalau order dibayar lewat beberapa transaksi
+func closeOrderTx(tx *sql.Tx, id int, transactionID *int) error {
+	_, err := tx.Exec("UPDATE orders SET status = $1, transaction_id = $2, updated_at = NOW() WHERE id = $3",
+		models.OrderCheckedOut, transactionID, id)
+	return err
+}
+
+// Checkout ubah order jadi transaksi memakai logic checkout yang sama dengan /api/checkout
+func (repo *OrderRepository) Checkout(id int, req models.OrderCheckoutRequest, loyalty models.LoyaltyConfig) (*models.Transaction, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, orderItems, err := lockOrderForSettlementTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.CheckoutItem, len(orderItems))
+	for i, item := range orderItems {
+		items[i] = models.CheckoutItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	transaction, err := createTransactionTx(tx, orderCheckoutRequest(order, items, 0, req.RedeemPoints, req.PaymentMethod), loyalty, repo.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = recordPaymentTx(tx, transaction.ID, order.ID, 1, 1, transaction.AmountDue)
+	if err != nil {
+		return nil, err
+	}
+
+	err = closeOrderTx(tx, id, &transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// recordPaymentTx catat satu pembayaran (bagian part dari parts) untuk sebuah transaksi
+func recordPaymentTx(tx *sql.Tx, transactionID, orderID, part, parts, amount int) (*models.Payment, error) {
+	payment := models.Payment{TransactionID: transactionID, Part: part, Parts: parts, Amount: amount}
+	if orderID > 0 {
+		payment.OrderID = &orderID
+	}
+	err := tx.QueryRow(`
+		INSERT INTO payments (transaction_id, order_id, part, parts, amount)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at
+	`, transactionID, payment.OrderID, part, parts, amount).Scan(&payment.ID, &payment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// SplitByItems bayar order lewat beberapa transaksi, tiap bagian membayar item yang dipilih.
+// Semua qty item order harus habis dibagi. Harga tier dihitung per transaksi
+func (repo *OrderRepository) SplitByItems(id int, parts []models.SplitPart, loyalty models.LoyaltyConfig) (*models.SplitResult, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, orderItems, err := lockOrderForSettlementTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations, err := allocateSplitItems(orderItems, parts)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]models.CheckoutRequest, len(parts))
+	for i, part := range parts {
+		requests[i] = orderCheckoutRequest(order, allocations[i], part.CustomerID, part.RedeemPoints, part.PaymentMethod)
+	}
+
+	result := models.SplitResult{OrderID: id, Mode: models.SplitByItems}
+	for i, req := range requests {
+		transaction, err := createTransactionTx(tx, req, loyalty, repo.loc)
+		if err != nil {
+			return nil, err
+		}
+		payment, err := recordPaymentTx(tx, transaction.ID, id, i+1, len(requests), transaction.AmountDue)
+		if err != nil {
+			return nil, err
+		}
+		result.Transactions = append(result.Transactions, *transaction)
+		result.Receipts = append(result.Receipts, receiptFor(*payment, transaction))
+	}
+
+	err = closeOrderTx(tx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// allocateSplitItems ubah item tiap bagian split jadi item checkout. Qty item order tidak boleh
+// dibagi melebihi sisanya dan semuanya harus habis dibagi
+func allocateSplitItems(orderItems []models.OrderItem, parts []models.SplitPart) ([][]models.CheckoutItem, error) {
+	remaining := make(map[int]models.OrderItem, len(orderItems))
+	for _, item := range orderItems {
+		remaining[item.ID] = item
+	}
+
+	allocations := make([][]models.CheckoutItem, len(parts))
+	for i, part := range parts {
+		items := make([]models.CheckoutItem, 0, len(part.Items))
+		for _, splitItem := range part.Items {
+			item, ok := remaining[splitItem.OrderItemID]
+			if !ok {
+				return nil, apperrors.Validation(apperrors.CodeInvalidReference, "order_item_id",
+					fmt.Sprintf("order item id %d tidak ada di order ini", splitItem.OrderItemID))
+			}
+			if splitItem.Quantity > item.Quantity {
+				return nil, apperrors.Validation(apperrors.CodeInvalid, "quantity",
+					fmt.Sprintf("qty order item id %d melebihi sisa (%d)", splitItem.OrderItemID, item.Quantity))
+			}
+			item.Quantity -= splitItem.Quantity
+			remaining[item.ID] = item
+			items = append(items, models.CheckoutItem{ProductID: item.ProductID, Quantity: splitItem.Quantity})
+		}
+		allocations[i] = items
+	}
+	for _, item := range orderItems {
+		if left := remaining[item.ID].Quantity; left > 0 {
+			return nil, apperrors.Validation(apperrors.CodeInvalid, "parts",
+				fmt.Sprintf("order item id %d masih tersisa %d yang belum dibagi", item.ID, left))
+		}
+	}
+	return allocations, nil
+}
+
+// SplitEven bayar order dalam satu transaksi yang dibagi rata ke beberapa pembayaran,
+// sisa pembagian (rupiah) dibebankan ke pembayar pertama dan seterusnya
+func (repo *OrderRepository) SplitEven(id, ways int, req models.OrderCheckoutRequest, loyalty models.LoyaltyConfig) (*models.SplitResult, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, orderItems, err := lockOrderForSettlementTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.CheckoutItem, len(orderItems))
+	for i, item := range orderItems {
+		items[i] = models.CheckoutItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	transaction, err := createTransactionTx(tx, orderCheckoutRequest(order, items, 0, req.RedeemPoints, req.PaymentMethod), loyalty, repo.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// lunas dengan poin tidak ada yang bisa dibagi. Kalau sisa bayar lebih kecil dari ways, pembayar terakhir dapat bagian 0
+	if transaction.AmountDue == 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "ways",
+			"Sisa bayar 0 setelah tukar poin, tidak ada yang bisa dibagi rata. Gunakan checkout biasa")
+	}
+
+	result := models.SplitResult{OrderID: id, Mode: models.SplitEven, Transactions: []models.Transaction{*transaction}}
+	for i, amount := range evenShares(transaction.AmountDue, ways) {
+		payment, err := recordPaymentTx(tx, transaction.ID, id, i+1, ways, amount)
+		if err != nil {
+			return nil, err
+		}
+		result.Receipts = append(result.Receipts, receiptFor(*payment, transaction))
+	}
+
+	err = closeOrderTx(tx, id, &transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// evenShares bagi amount ke ways bagian, sisa pembagian dibebankan 1 per bagian mulai bagian pertama
+func evenShares(amount, ways int) []int {
+	shares := make([]int, ways)
+	share, remainder := amount/ways, amount%ways
+	for i := range shares {
+		shares[i] = share
+		if i < remainder {
+			shares[i]++
+		}
+	}
+	return shares
+}
+
+func receiptFor(payment models.Payment, transaction *models.Transaction) models.Receipt {
+	return models.Receipt{
+		Payment:        payment,
+		Items:          transaction.Details,
+		TotalAmount:    transaction.TotalAmount,
+		PointsRedeemed: transaction.PointsRedeemed,
+		PointsEarned:   transaction.PointsEarned,
+		AmountDue:      transaction.AmountDue,
+		PaymentMethod:  transaction.PaymentMethod,
+	}
+}
+
+// GetReceipts struk semua pembayaran sebuah order, urut per transaksi lalu bagian
+func (repo *OrderRepository) GetReceipts(orderID int) ([]models.Receipt, error) {
+	rows, err := repo.db.Query(`
+		SELECT pm.id, pm.transaction_id, pm.order_id, pm.part, pm.parts, pm.amount, pm.created_at,
+		       t.total_amount, t.points_redeemed, t.points_earned, COALESCE(t.amount_due, t.total_amount), t.payment_method
+		FROM payments pm
+		JOIN transactions t ON pm.transaction_id = t.id
+		WHERE pm.order_id = $1
+		ORDER BY pm.transaction_id, pm.part
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := make([]models.Receipt, 0)
+	transactionIDs := make([]int64, 0)
+	for rows.Next() {
+		var r models.Receipt
+		err := rows.Scan(&r.Payment.ID, &r.Payment.TransactionID, &r.Payment.OrderID, &r.Payment.Part, &r.Payment.Parts,
+			&r.Payment.Amount, &r.Payment.CreatedAt, &r.TotalAmount, &r.PointsRedeemed, &r.PointsEarned, &r.AmountDue, &r.PaymentMethod)
+		if err != nil {
+			return nil, err
+		}
+		r.Items = make([]models.TransactionDetail, 0)
+		receipts = append(receipts, r)
+		transactionIDs = append(transactionIDs, int64(r.Payment.TransactionID))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(receipts) == 0 {
+		return receipts, nil
+	}
+
+	detailRows, err := repo.db.Query(`
+		SELECT td.id, td.transaction_id, td.product_id, p.name, td.quantity,
+		       COALESCE(td.unit_price, td.subtotal / NULLIF(td.quantity, 0), 0), COALESCE(pl.code, ''), td.subtotal
+		FROM transaction_details td
+		JOIN product p ON td.product_id = p.id
+		LEFT JOIN price_list pl ON td.price_list_id = pl.id
+		WHERE td.transaction_id = ANY($1)
+		ORDER BY td.id
+	`, pq.Array(transactionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer detailRows.Close()
+
+	details := make(map[int][]models.TransactionDetail)
+	for detailRows.Next() {
+		var d models.TransactionDetail
+		err := detailRows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.Quantity, &d.UnitPrice, &d.PriceList, &d.Subtotal)
+		if err != nil {
+			return nil, err
+		}
+		details[d.TransactionID] = append(details[d.TransactionID], d)
+	}
+	if err := detailRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range receipts {
+		if d, ok := details[receipts[i].Payment.TransactionID]; ok {
+			receipts[i].Items = d
+		}
+	}
+	return receipts, nil
+}
+
+// SendToKitchen kirim semua item yang belum dikirim sebagai satu tiket ronde berikutnya
+func (repo *OrderRepository) SendToKitchen(orderID int) (*models.KitchenTicket, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := lockEditableOrder(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending int
+	err = tx.QueryRow("SELECT COUNT(*) FROM order_items WHERE order_id = $1 AND ticket_id IS NULL", orderID).Scan(&pending)
+	if err != nil {
+		return nil, err
+	}
+	if pending == 0 {
+		return nil, apperrors.Validation(apperrors.CodeRequired, "items", "Tidak ada item baru untuk dikirim ke dapur")
+	}
+
+	ticket := models.KitchenTicket{OrderID: orderID, TableName: order.TableName}
+	err = tx.QueryRow(`
+		INSERT INTO kitchen_tickets (order_id, round)
+		SELECT $1, COALESCE(MAX(round), 0) + 1 FROM kitchen_tickets WHERE order_id = $1
+		RETURNING id, round, created_at
+	`, orderID).Scan(&ticket.ID, &ticket.Round, &ticket.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE order_items SET ticket_id = $1 WHERE order_id = $2 AND ticket_id IS NULL", ticket.ID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = touchOrder(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	tickets, err := repo.GetTickets(orderID, ticket.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &tickets[0], nil
+}
+
+// GetTickets tiket dapur sebuah order urut per ronde, ticketID > 0 untuk satu tiket saja
+func (repo *OrderRepository) GetTickets(orderID, ticketID int) ([]models.KitchenTicket, error) {
+	rows, err := repo.db.Query(`
+		SELECT kt.id, kt.order_id, COALESCE(dt.name, ''), kt.round, kt.created_at,
+		       oi.id, oi.product_id, p.name, oi.quantity
+		FROM kitchen_tickets kt
+		JOIN orders o ON kt.order_id = o.id
+		LEFT JOIN dining_tables dt ON o.table_id = dt.id
+		JOIN order_items oi ON oi.ticket_id = kt.id
+		JOIN product p ON oi.product_id = p.id
+		WHERE kt.order_id = $1 AND ($2 = 0 OR kt.id = $2)
+		ORDER BY kt.round, oi.id
+	`, orderID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := make([]models.KitchenTicket, 0)
+	for rows.Next() {
+		var t models.KitchenTicket
+		var item models.KitchenTicketItem
+		err := rows.Scan(&t.ID, &t.OrderID, &t.TableName, &t.Round, &t.CreatedAt,
+			&item.OrderItemID, &item.ProductID, &item.ProductName, &item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if len(tickets) == 0 || tickets[len(tickets)-1].ID != t.ID {
+			t.Items = make([]models.KitchenTicketItem, 0)
+			tickets = append(tickets, t)
+		}
+		last := &tickets[len(tickets)-1]
+		last.Items = append(last.Items, item)
+	}
+	return tickets, rows.Err()
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"errors"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"reflect"
+	"testing"
+)
+
+func TestEvenShares(t *testing.T) {
+	tests := []struct {
+		amount, ways int
+		want         []int
+	}{
+		{100, 2, []int{50, 50}},
+		{100, 3, []int{34, 33, 33}},
+		{10001, 4, []int{2501, 2500, 2500, 2500}},
+		{5, 5, []int{1, 1, 1, 1, 1}},
+		{7, 3, []int{3, 2, 2}},
+		{2, 4, []int{1, 1, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		got := evenShares(tt.amount, tt.ways)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("evenShares(%d, %d) = %v, want %v", tt.amount, tt.ways, got, tt.want)
+		}
+		sum := 0
+		for _, share := range got {
+			sum += share
+		}
+		if sum != tt.amount {
+			t.Errorf("evenShares(%d, %d) jumlahnya %d", tt.amount, tt.ways, sum)
+		}
+	}
+}
+
+func TestAllocateSplitItems(t *testing.T) {
+	orderItems := []models.OrderItem{
+		{ID: 1, ProductID: 10, Quantity: 2},
+		{ID: 2, ProductID: 20, Quantity: 1},
+	}
+	part := func(items ...models.SplitItem) models.SplitPart { return models.SplitPart{Items: items} }
+
+	t.Run("habis dibagi", func(t *testing.T) {
+		got, err := allocateSplitItems(orderItems, []models.SplitPart{
+			part(models.SplitItem{OrderItemID: 1, Quantity: 1}),
+			part(models.SplitItem{OrderItemID: 1, Quantity: 1}, models.SplitItem{OrderItemID: 2, Quantity: 1}),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := [][]models.CheckoutItem{
+			{{ProductID: 10, Quantity: 1}},
+			{{ProductID: 10, Quantity: 1}, {ProductID: 20, Quantity: 1}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("allocateSplitItems() = %v, want %v", got, want)
+		}
+	})
+
+	errTests := []struct {
+		name  string
+		parts []models.SplitPart
+		field string
+	}{
+		{"item bukan milik order", []models.SplitPart{
+			part(models.SplitItem{OrderItemID: 1, Quantity: 2}),
+			part(models.SplitItem{OrderItemID: 3, Quantity: 1}),
+		}, "order_item_id"},
+		{"melebihi sisa", []models.SplitPart{
+			part(models.SplitItem{OrderItemID: 1, Quantity: 2}, models.SplitItem{OrderItemID: 2, Quantity: 1}),
+			part(models.SplitItem{OrderItemID: 1, Quantity: 1}),
+		}, "quantity"},
+		{"masih tersisa", []models.SplitPart{
+			part(models.SplitItem{OrderItemID: 1, Quantity: 1}),
+			part(models.SplitItem{OrderItemID: 2, Quantity: 1}),
+		}, "parts"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := allocateSplitItems(orderItems, tt.parts)
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) || appErr.Kind != apperrors.ErrValidation || appErr.Field != tt.field {
+				t.Fatalf("err = %v, want validasi field %s", err, tt.field)
+			}
+		})
+	}
+}
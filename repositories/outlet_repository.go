@@ -0,0 +1,300 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+)
+
+// kode outlet default untuk data lama dan request yang tidak menyebutkan outlet
+const DefaultOutletCode = "main"
+
+type OutletRepository struct {
+	db *sql.DB
+}
+
+func NewOutletRepository(db *sql.DB) *OutletRepository {
+	return &OutletRepository{db: db}
+}
+
+const outletColumns = `id, code, name, address, archived, archived_at, created_at`
+
+func scanOutlet(row interface{ Scan(...interface{}) error }, o *models.Outlet) error {
+	return row.Scan(&o.ID, &o.Code, &o.Name, &o.Address, &o.Archived, &o.ArchivedAt, &o.CreatedAt)
+}
+
+func (repo *OutletRepository) GetAll(includeArchived bool) ([]models.Outlet, error) {
+	query := "SELECT " + outletColumns + " FROM outlet"
+	if !includeArchived {
+		query += " WHERE archived = FALSE"
+	}
+	query += " ORDER BY id"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outlets := make([]models.Outlet, 0)
+	for rows.Next() {
+		var o models.Outlet
+		err := scanOutlet(rows, &o)
+		if err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, o)
+	}
+	return outlets, rows.Err()
+}
+
+func (repo *OutletRepository) GetByID(id int) (*models.Outlet, error) {
+	var o models.Outlet
+	err := scanOutlet(repo.db.QueryRow("SELECT "+outletColumns+" FROM outlet WHERE id = $1", id), &o)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Outlet tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (repo *OutletRepository) Create(outlet *models.Outlet) error {
+	query := "INSERT INTO outlet (code, name, address) VALUES ($1, $2, $3) RETURNING id, created_at"
+	return repo.db.QueryRow(query, outlet.Code, outlet.Name, outlet.Address).Scan(&outlet.ID, &outlet.CreatedAt)
+}
+
+func (repo *OutletRepository) Update(outlet *models.Outlet) error {
+	result, err := repo.db.Exec("UPDATE outlet SET code = $1, name = $2, address = $3 WHERE id = $4",
+		outlet.Code, outlet.Name, outlet.Address, outlet.ID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperrors.NotFound("Outlet tidak ditemukan")
+	}
+	return nil
+}
+
+// Delete archive outlet, outlet default dan outlet yang masih punya stok atau order terbuka ditolak
+func (repo *OutletRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var code string
+	var archived bool
+	err = tx.QueryRow("SELECT code, archived FROM outlet WHERE id = $1 FOR UPDATE", id).Scan(&code, &archived)
+	if err == sql.ErrNoRows || (err == nil && archived) {
+		return apperrors.NotFound("Outlet tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+	if code == DefaultOutletCode {
+		return apperrors.Conflict("Outlet default tidak bisa di-archive")
+	}
+
+	var stock, openOrders int
+	err = tx.QueryRow("SELECT COALESCE(SUM(stock), 0) FROM outlet_stock WHERE outlet_id = $1", id).Scan(&stock)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow("SELECT COUNT(*) FROM orders WHERE outlet_id = $1 AND status IN ($2, $3)",
+		id, models.OrderDraft, models.OrderParked).Scan(&openOrders)
+	if err != nil {
+		return err
+	}
+	if stock > 0 || openOrders > 0 {
+		return apperrors.Conflict(fmt.Sprintf("Outlet masih punya %d stok dan %d order terbuka", stock, openOrders))
+	}
+
+	// stok yang sedang di jalan hanya bisa diterima atau dikembalikan selama kedua outlet aktif
+	var shippedTransfers int
+	err = tx.QueryRow("SELECT COUNT(*) FROM stock_transfer WHERE (from_outlet_id = $1 OR to_outlet_id = $1) AND status = $2",
+		id, models.TransferShipped).Scan(&shippedTransfers)
+	if err != nil {
+		return err
+	}
+	if shippedTransfers > 0 {
+		return apperrors.Conflict(fmt.Sprintf("Outlet masih punya %d transfer stok yang sedang dikirim", shippedTransfers))
+	}
+
+	_, err = tx.Exec("UPDATE outlet SET archived = TRUE, archived_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Restore outlet yang sudah di-archive
+func (repo *OutletRepository) Restore(id int) error {
+	result, err := repo.db.Exec("UPDATE outlet SET archived = FALSE, archived_at = NULL WHERE id = $1 AND archived = TRUE", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperrors.NotFound("Outlet tidak ditemukan atau tidak di-archive")
+	}
+	return nil
+}
+
+// GetStock stok semua produk aktif di outlet, produk yang belum pernah punya stok di outlet tampil 0
+func (repo *OutletRepository) GetStock(outletID int) ([]models.OutletStock, error) {
+	rows, err := repo.db.Query(`
+		SELECT p.id, p.name, COALESCE(os.stock, 0), COALESCE(os.reserved_stock, 0)
+		FROM product p
+		LEFT JOIN outlet_stock os ON os.product_id = p.id AND os.outlet_id = $1
+		WHERE p.archived = FALSE
+		ORDER BY p.name
+	`, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make([]models.OutletStock, 0)
+	for rows.Next() {
+		s := models.OutletStock{OutletID: outletID}
+		err := rows.Scan(&s.ProductID, &s.ProductName, &s.Stock, &s.ReservedStock)
+		if err != nil {
+			return nil, err
+		}
+		s.Available = s.Stock - s.ReservedStock
+		stocks = append(stocks, s)
+	}
+	return stocks, rows.Err()
+}
+
+// SetStock set stok beberapa produk di outlet, selisihnya ikut mengubah total product.stock
+func (repo *OutletRepository) SetStock(outletID int, updates []models.OutletStockUpdate) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = checkOutletTx(tx, outletID)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		var exists bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product WHERE id = $1)", u.ProductID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return apperrors.Validation(apperrors.CodeInvalidReference, "product_id", fmt.Sprintf("product id %d not found", u.ProductID))
+		}
+
+		var current int
+		err = tx.QueryRow("SELECT stock FROM outlet_stock WHERE outlet_id = $1 AND product_id = $2 FOR UPDATE", outletID, u.ProductID).Scan(&current)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		err = adjustOutletStock(tx, outletID, u.ProductID, u.Stock-current)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// resolveOutletTx outletID 0 berarti outlet default, outlet lain harus ada dan aktif
+func resolveOutletTx(tx *sql.Tx, outletID int) (int, error) {
+	if outletID == 0 {
+		err := tx.QueryRow("SELECT id FROM outlet WHERE code = $1", DefaultOutletCode).Scan(&outletID)
+		return outletID, err
+	}
+	return outletID, checkOutletTx(tx, outletID)
+}
+
+func checkOutletTx(tx *sql.Tx, outletID int) error {
+	var archived bool
+	err := tx.QueryRow("SELECT archived FROM outlet WHERE id = $1", outletID).Scan(&archived)
+	if err == sql.ErrNoRows || (err == nil && archived) {
+		return apperrors.Validation(apperrors.CodeInvalidReference, "outlet_id", fmt.Sprintf("outlet id %d not found", outletID))
+	}
+	return err
+}
+
+// lockOutletStock kunci stok produk di outlet, hasilnya stok yang belum di-reserve
+func lockOutletStock(tx *sql.Tx, outletID, productID int) (int, error) {
+	var available int
+	err := tx.QueryRow("SELECT stock - reserved_stock FROM outlet_stock WHERE outlet_id = $1 AND product_id = $2 FOR UPDATE",
+		outletID, productID).Scan(&available)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return available, err
+}
+
+// adjustOutletStock ubah stok produk di outlet sebesar delta, total product.stock ikut disesuaikan.
+// Gagal kalau stok outlet jadi lebih kecil dari yang sedang di-reserve
+func adjustOutletStock(tx *sql.Tx, outletID, productID, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	var available int
+	err := tx.QueryRow(`
+		INSERT INTO outlet_stock (outlet_id, product_id, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (outlet_id, product_id) DO UPDATE SET stock = outlet_stock.stock + EXCLUDED.stock
+		RETURNING stock - reserved_stock
+	`, outletID, productID, delta).Scan(&available)
+	if err != nil {
+		return err
+	}
+	if available < 0 {
+		return apperrors.InsufficientStock("stock", fmt.Sprintf("stok product id %d di outlet tidak cukup", productID))
+	}
+
+	_, err = tx.Exec("UPDATE product SET stock = stock + $1 WHERE id = $2", delta, productID)
+	return err
+}
+
+// reserveStock tahan stok outlet untuk order, gagal kalau stok yang tersedia tidak cukup
+func reserveStock(tx *sql.Tx, outletID, productID, quantity int) error {
+	result, err := tx.Exec(`
+		UPDATE outlet_stock SET reserved_stock = reserved_stock + $1
+		WHERE outlet_id = $2 AND product_id = $3 AND stock - reserved_stock >= $1
+	`, quantity, outletID, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperrors.InsufficientStock("quantity", fmt.Sprintf("stok product id %d tidak cukup untuk di-reserve", productID))
+	}
+
+	_, err = tx.Exec("UPDATE product SET reserved_stock = reserved_stock + $1 WHERE id = $2", quantity, productID)
+	return err
+}
+
+func releaseStock(tx *sql.Tx, outletID, productID, quantity int) error {
+	_, err := tx.Exec("UPDATE outlet_stock SET reserved_stock = GREATEST(reserved_stock - $1, 0) WHERE outlet_id = $2 AND product_id = $3",
+		quantity, outletID, productID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE product SET reserved_stock = GREATEST(reserved_stock - $1, 0) WHERE id = $2", quantity, productID)
+	return err
+}
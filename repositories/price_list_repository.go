@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+)
+
+// kode daftar harga default kalau checkout tidak menyebutkan daftar harga
+const RetailPriceList = "retail"
+
+type PriceListRepository struct {
+	db *sql.DB
+}
+
+func NewPriceListRepository(db *sql.DB) *PriceListRepository {
+	return &PriceListRepository{db: db}
+}
+
+func (repo *PriceListRepository) GetAll() ([]models.PriceList, error) {
+	rows, err := repo.db.Query("SELECT id, code, name FROM price_list ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	priceLists := make([]models.PriceList, 0)
+	for rows.Next() {
+		var pl models.PriceList
+		err := rows.Scan(&pl.ID, &pl.Code, &pl.Name)
+		if err != nil {
+			return nil, err
+		}
+		priceLists = append(priceLists, pl)
+	}
+	return priceLists, nil
+}
+
+func (repo *PriceListRepository) Create(priceList *models.PriceList) error {
+	query := "INSERT INTO price_list (code, name) VALUES ($1, $2) RETURNING id"
+	return repo.db.QueryRow(query, priceList.Code, priceList.Name).Scan(&priceList.ID)
+}
+
+func (repo *PriceListRepository) GetByCode(code string) (*models.PriceList, error) {
+	var pl models.PriceList
+	err := repo.db.QueryRow("SELECT id, code, name FROM price_list WHERE code = $1", code).Scan(&pl.ID, &pl.Code, &pl.Name)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Daftar harga tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pl, nil
+}
+
+// GetProductPrices semua tier harga satu produk
+func (repo *PriceListRepository) GetProductPrices(productID int) ([]models.ProductPriceTier, error) {
+	query := `
+        SELECT pp.id, pp.product_id, pl.code, pp.min_qty, pp.price
+        FROM product_price pp
+        JOIN price_list pl ON pp.price_list_id = pl.id
+        WHERE pp.product_id = $1
+        ORDER BY pl.id, pp.min_qty`
+	rows, err := repo.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tiers := make([]models.ProductPriceTier, 0)
+	for rows.Next() {
+		var t models.ProductPriceTier
+		err := rows.Scan(&t.ID, &t.ProductID, &t.PriceList, &t.MinQty, &t.Price)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, t)
+	}
+	return tiers, nil
+}
+
+// ReplaceProductPrices ganti semua tier harga produk dalam satu transaksi
+func (repo *PriceListRepository) ReplaceProductPrices(productID int, tiers []models.ProductPriceTier) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM product_price WHERE product_id = $1", productID)
+	if err != nil {
+		return err
+	}
+
+	for i := range tiers {
+		tiers[i].ProductID = productID
+		err = tx.QueryRow(`
+            INSERT INTO product_price (product_id, price_list_id, min_qty, price)
+            SELECT $1, id, $3, $4 FROM price_list WHERE code = $2
+            RETURNING id`, productID, tiers[i].PriceList, tiers[i].MinQty, tiers[i].Price).Scan(&tiers[i].ID)
+		if err == sql.ErrNoRows {
+			return apperrors.Validation(apperrors.CodeInvalidReference, "price_list", "Daftar harga "+tiers[i].PriceList+" tidak ditemukan")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -2,8 +2,10 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
+	"kasir-api/apperrors"
 	"kasir-api/models"
+	"strings"
 )
 
 type ProductRepository struct {
@@ -14,7 +16,7 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (repo *ProductRepository) GetAll(nameFilter string) ([]models.Product, error) {
+func (repo *ProductRepository) GetAll(filter models.ProductFilter) ([]models.Product, error) {
 	// query := "SELECT id, name, price, stock FROM product"
 	// rows, err := repo.db.Query(query)
 	// if err != nil {
@@ -37,16 +39,29 @@ func (repo *ProductRepository) GetAll(nameFilter string) ([]models.Product, erro
 		Update queries while joining categories table
 	*/
 	query := `
-        SELECT p.id, p.name, p.price, p.stock, p.category_id, 
+        SELECT p.id, p.name, p.price, p.stock, COALESCE(p.category_id, 0), 
                COALESCE(c.name, '') as category_name, 
-               COALESCE(c.description, '') as category_description 
+               COALESCE(c.description, '') as category_description,
+               p.archived, p.archived_at, p.version, p.cost
         FROM product p 
         LEFT JOIN category c ON p.category_id = c.id`
 
+	// produk yang di-archive tidak ikut tampil kecuali diminta
+	conditions := []string{}
 	args := []interface{}{}
-	if nameFilter != "" {
-		query += " WHERE p.name ILIKE $1"
-		args = append(args, "%"+nameFilter+"%")
+	if !filter.IncludeArchived {
+		conditions = append(conditions, "p.archived = FALSE")
+	}
+	if filter.Name != "" {
+		args = append(args, "%"+filter.Name+"%")
+		conditions = append(conditions, fmt.Sprintf("p.name ILIKE $%d", len(args)))
+	}
+	if filter.CategoryID > 0 {
+		args = append(args, filter.CategoryID)
+		conditions = append(conditions, "p.category_id IN ("+categorySubtree(fmt.Sprintf("$%d", len(args)))+")")
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	rows, err := repo.db.Query(query, args...)
@@ -59,7 +74,7 @@ func (repo *ProductRepository) GetAll(nameFilter string) ([]models.Product, erro
 	for rows.Next() {
 		var p models.Product
 
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName, &p.CategoryDescription)
+		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName, &p.CategoryDescription, &p.Archived, &p.ArchivedAt, &p.Version, &p.Cost)
 		if err != nil {
 			return nil, err
 		}
@@ -78,9 +93,35 @@ func (repo *ProductRepository) Create(product *models.Product) error {
 		update queries while joining categories table
 	*/
 
-	query := "INSERT INTO product (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id"
-	err := repo.db.QueryRow(query, product.Name, product.Price, product.Stock, product.CategoryID).Scan(&product.ID)
-	return err
+	// category_id 0 berarti produk tanpa kategori
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// stok awal masuk ke outlet default, product.stock diisi lewat adjustOutletStock
+	query := "INSERT INTO product (name, price, stock, category_id, cost) VALUES ($1, $2, 0, NULLIF($3, 0), $4) RETURNING id, version"
+	err = tx.QueryRow(query, product.Name, product.Price, product.CategoryID, product.Cost).Scan(&product.ID, &product.Version)
+	if err != nil {
+		return err
+	}
+
+	outletID, err := resolveOutletTx(tx, 0)
+	if err != nil {
+		return err
+	}
+	err = adjustOutletStock(tx, outletID, product.ID, product.Stock)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO product_price_history (product_id, old_price, new_price) VALUES ($1, NULL, $2)", product.ID, product.Price)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Product GetByID
@@ -101,9 +142,10 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 		update query while to joining categories table
 	*/
 	query := `
-        SELECT p.id, p.name, p.price, p.stock, p.category_id, 
+        SELECT p.id, p.name, p.price, p.stock, COALESCE(p.category_id, 0), 
                COALESCE(c.name, '') as category_name, 
-               COALESCE(c.description, '') as category_description 
+               COALESCE(c.description, '') as category_description,
+               p.archived, p.archived_at, p.version, p.cost
         FROM product p 
         LEFT JOIN category c ON p.category_id = c.id 
         WHERE p.id = $1`
@@ -111,10 +153,10 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 	var p models.Product
 
 	err := repo.db.QueryRow(query, id).Scan(
-		&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName, &p.CategoryDescription)
+		&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName, &p.CategoryDescription, &p.Archived, &p.ArchivedAt, &p.Version, &p.Cost)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("Produk tidak ditemukan")
+		return nil, apperrors.NotFound("Produk tidak ditemukan")
 	}
 	if err != nil {
 		return nil, err
@@ -123,8 +165,9 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 	return &p, nil
 }
 
-// Update Produk
-func (repo *ProductRepository) Update(product *models.Product) error {
+// Update Produk. setStock false berarti stok tidak disentuh, product.Stock diisi stok terkini dari database
+// supaya update lain (misalnya ganti harga) tidak menimpa stok yang berubah karena checkout
+func (repo *ProductRepository) Update(product *models.Product, setStock bool) error {
 	// query := "UPDATE product SET name = $1, price = $2, stock = $3 WHERE id = $4"
 	// result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.ID)
 	// if err != nil {
@@ -145,27 +188,150 @@ func (repo *ProductRepository) Update(product *models.Product) error {
 	/*
 		update queries while joining category table
 	*/
-	query := "UPDATE product SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5"
-	result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.CategoryID, product.ID)
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	var oldPrice, oldStock int
+	err = tx.QueryRow("SELECT price, stock FROM product WHERE id = $1 FOR UPDATE", product.ID).Scan(&oldPrice, &oldStock)
+	if err == sql.ErrNoRows {
+		return apperrors.NotFound("Produk tidak ditemukan")
+	}
 	if err != nil {
 		return err
 	}
 
+	// product.Version > 0 berarti update hanya boleh kalau versi di database masih sama
+	query := `
+        UPDATE product SET name = $1, price = $2, category_id = NULLIF($3, 0), cost = $6, version = version + 1
+        WHERE id = $4 AND ($5 = 0 OR version = $5)
+        RETURNING version`
+	err = tx.QueryRow(query, product.Name, product.Price, product.CategoryID, product.ID, product.Version, product.Cost).Scan(&product.Version)
+	if err == sql.ErrNoRows {
+		return apperrors.PreconditionFailed("Produk sudah diubah oleh orang lain, ambil data terbaru dulu")
+	}
+	if err != nil {
+		return err
+	}
+
+	// stok di sini total semua outlet, selisihnya dibebankan ke outlet default.
+	// Stok outlet lain diatur lewat /outlets/{id}/stock
+	if setStock {
+		outletID, err := resolveOutletTx(tx, 0)
+		if err != nil {
+			return err
+		}
+		err = adjustOutletStock(tx, outletID, product.ID, product.Stock-oldStock)
+		if err != nil {
+			return err
+		}
+	} else {
+		product.Stock = oldStock
+	}
+
+	if oldPrice != product.Price {
+		_, err = tx.Exec("INSERT INTO product_price_history (product_id, old_price, new_price) VALUES ($1, $2, $3)", product.ID, oldPrice, product.Price)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete produk (soft delete, data tetap ada untuk histori transaksi). Produk ini dihapus dari order
+// yang masih terbuka dan stok yang ditahan order tersebut dilepas, karena produk archive tidak bisa di-checkout
+func (repo *ProductRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = removeFromOpenOrdersTx(tx, id)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE product SET archived = TRUE, archived_at = NOW() WHERE id = $1 AND archived = FALSE"
+	result, err := tx.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+		return apperrors.NotFound("Produk tidak ditemukan")
+	}
+	return tx.Commit()
+}
+
+// removeFromOpenOrdersTx hapus baris produk dari order draft / parked dan lepas stok yang ditahan.
+// Order dikunci lebih dulu dari produk, urutan yang sama dengan checkout order, supaya tidak deadlock.
+// Baris yang sudah dikirim ke dapur tidak dihapus, archive ditolak
+func removeFromOpenOrdersTx(tx *sql.Tx, productID int) error {
+	rows, err := tx.Query(`
+		SELECT oi.id, oi.quantity, oi.ticket_id IS NOT NULL, o.id, COALESCE(o.outlet_id, 0), o.stock_reserved
+		FROM order_items oi
+		JOIN orders o ON oi.order_id = o.id
+		WHERE oi.product_id = $1 AND o.status IN ($2, $3)
+		ORDER BY o.id, oi.id
+		FOR UPDATE OF o, oi
+	`, productID, models.OrderDraft, models.OrderParked)
+	if err != nil {
+		return err
 	}
 
+	type openLine struct {
+		itemID, quantity, orderID, outletID int
+		sent, reserved                      bool
+	}
+	var lines []openLine
+	for rows.Next() {
+		var l openLine
+		err := rows.Scan(&l.itemID, &l.quantity, &l.sent, &l.orderID, &l.outletID, &l.reserved)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		lines = append(lines, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, l := range lines {
+		if l.sent {
+			return apperrors.Conflict(fmt.Sprintf("Produk sudah dikirim ke dapur di order %d, selesaikan order itu dulu", l.orderID))
+		}
+	}
+	for _, l := range lines {
+		if l.reserved {
+			err = releaseStock(tx, l.outletID, productID, l.quantity)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec("DELETE FROM order_items WHERE id = $1", l.itemID)
+		if err != nil {
+			return err
+		}
+		err = touchOrder(tx, l.orderID)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Delete produk
-func (repo *ProductRepository) Delete(id int) error {
-	query := "DELETE FROM product WHERE id = $1"
+// Restore produk yang sudah di-archive
+func (repo *ProductRepository) Restore(id int) error {
+	query := "UPDATE product SET archived = FALSE, archived_at = NULL WHERE id = $1 AND archived = TRUE"
 	result, err := repo.db.Exec(query, id)
 	if err != nil {
 		return err
@@ -175,7 +341,151 @@ func (repo *ProductRepository) Delete(id int) error {
 		return err
 	}
 	if rows == 0 {
-		return errors.New("Produk tidak ditemukan")
+		return apperrors.NotFound("Produk archived tidak ditemukan")
+	}
+	return nil
+}
+
+// GetPriceHistory histori harga produk, terbaru di atas
+func (repo *ProductRepository) GetPriceHistory(productID int) ([]models.PriceHistory, error) {
+	query := `
+        SELECT id, product_id, old_price, new_price, source, schedule_id, changed_at
+        FROM product_price_history
+        WHERE product_id = $1
+        ORDER BY changed_at DESC, id DESC`
+	rows, err := repo.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]models.PriceHistory, 0)
+	for rows.Next() {
+		var h models.PriceHistory
+		err := rows.Scan(&h.ID, &h.ProductID, &h.OldPrice, &h.NewPrice, &h.Source, &h.ScheduleID, &h.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
 	}
-	return err
+	return history, nil
+}
+
+const priceScheduleColumns = `id, product_id, new_price, effective_at, applied_at, cancelled_at, created_at,
+               CASE WHEN applied_at IS NOT NULL THEN 'applied'
+                    WHEN cancelled_at IS NOT NULL THEN 'cancelled'
+                    ELSE 'pending' END`
+
+func scanPriceSchedule(row interface{ Scan(...interface{}) error }, ps *models.PriceSchedule) error {
+	return row.Scan(&ps.ID, &ps.ProductID, &ps.NewPrice, &ps.EffectiveAt, &ps.AppliedAt, &ps.CancelledAt, &ps.CreatedAt, &ps.Status)
+}
+
+// GetPriceSchedules jadwal perubahan harga produk, urut waktu berlaku
+func (repo *ProductRepository) GetPriceSchedules(productID int) ([]models.PriceSchedule, error) {
+	query := "SELECT " + priceScheduleColumns + " FROM product_price_schedule WHERE product_id = $1 ORDER BY effective_at, id"
+	rows, err := repo.db.Query(query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]models.PriceSchedule, 0)
+	for rows.Next() {
+		var ps models.PriceSchedule
+		err := scanPriceSchedule(rows, &ps)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, ps)
+	}
+	return schedules, nil
+}
+
+func (repo *ProductRepository) CreatePriceSchedule(schedule *models.PriceSchedule) error {
+	query := `
+        INSERT INTO product_price_schedule (product_id, new_price, effective_at)
+        VALUES ($1, $2, $3)
+        RETURNING ` + priceScheduleColumns
+	return scanPriceSchedule(repo.db.QueryRow(query, schedule.ProductID, schedule.NewPrice, schedule.EffectiveAt), schedule)
+}
+
+// CancelPriceSchedule batalkan jadwal yang belum berlaku
+func (repo *ProductRepository) CancelPriceSchedule(productID, scheduleID int) error {
+	query := `
+        UPDATE product_price_schedule SET cancelled_at = NOW()
+        WHERE id = $1 AND product_id = $2 AND applied_at IS NULL AND cancelled_at IS NULL`
+	result, err := repo.db.Exec(query, scheduleID, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperrors.NotFound("Jadwal harga yang masih pending tidak ditemukan")
+	}
+	return nil
+}
+
+// ApplyDuePriceSchedules terapkan semua jadwal harga yang sudah waktunya,
+// return jumlah jadwal yang diterapkan
+func (repo *ProductRepository) ApplyDuePriceSchedules() (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// SKIP LOCKED supaya aman kalau ada lebih dari satu instance yang jalan
+	rows, err := tx.Query(`
+        SELECT id, product_id, new_price FROM product_price_schedule
+        WHERE applied_at IS NULL AND cancelled_at IS NULL AND effective_at <= NOW()
+        ORDER BY effective_at, id
+        FOR UPDATE SKIP LOCKED`)
+	if err != nil {
+		return 0, err
+	}
+
+	due := make([]models.PriceSchedule, 0)
+	for rows.Next() {
+		var ps models.PriceSchedule
+		err := rows.Scan(&ps.ID, &ps.ProductID, &ps.NewPrice)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, ps)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, ps := range due {
+		var oldPrice int
+		err := tx.QueryRow("SELECT price FROM product WHERE id = $1 FOR UPDATE", ps.ProductID).Scan(&oldPrice)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec("UPDATE product SET price = $1, version = version + 1 WHERE id = $2", ps.NewPrice, ps.ProductID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`
+            INSERT INTO product_price_history (product_id, old_price, new_price, source, schedule_id)
+            VALUES ($1, $2, $3, 'schedule', $4)`, ps.ProductID, oldPrice, ps.NewPrice, ps.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec("UPDATE product_price_schedule SET applied_at = NOW() WHERE id = $1", ps.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(due), tx.Commit()
 }
@@ -3,49 +3,430 @@ package repositories
 import (
 	"database/sql"
 	"kasir-api/models"
+	"math"
+	"sort"
 	"time"
 )
 
 type ReportRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	loc *time.Location // zona waktu toko, hari bisnis di ringkasan harian mengikuti ini
 }
 
-func NewReportRepository(db *sql.DB) *ReportRepository {
-	return &ReportRepository{db: db}
+func NewReportRepository(db *sql.DB, loc *time.Location) *ReportRepository {
+	return &ReportRepository{db: db, loc: loc}
 }
 
-func (r *ReportRepository) GetDailyReport() (*models.Report, error) {
-	today := time.Now().Format("2006-01-02")
-	return r.GetReportByDateRange(today, today)
+// GetReportByDateRange laporan transaksi dengan created_at dalam [start, end),
+// outletID 0 berarti semua outlet, topN jumlah produk di setiap peringkat
+func (r *ReportRepository) GetReportByDateRange(start, end time.Time, outletID, topN int) (*models.Report, error) {
+	var report models.Report
+
+	var err error
+	report.TotalRevenue, report.TotalTransaction, err = r.GetTotals(start, end, outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	sales, err := r.getProductSales(start, end, outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	report.TopByQty = rankProducts(sales, topN, func(a, b models.ProductSales) bool {
+		if a.QtyTerjual != b.QtyTerjual {
+			return a.QtyTerjual > b.QtyTerjual
+		}
+		return a.Revenue > b.Revenue
+	})
+	report.TopByRevenue = rankProducts(sales, topN, func(a, b models.ProductSales) bool {
+		if a.Revenue != b.Revenue {
+			return a.Revenue > b.Revenue
+		}
+		return a.QtyTerjual > b.QtyTerjual
+	})
+	report.SlowMovers = rankProducts(sales, topN, func(a, b models.ProductSales) bool {
+		if a.QtyTerjual != b.QtyTerjual {
+			return a.QtyTerjual < b.QtyTerjual
+		}
+		return a.Revenue < b.Revenue
+	})
+	if len(report.TopByQty) > 0 {
+		report.ProdukTerlaris = models.ProdukTerlaris{Nama: report.TopByQty[0].Nama, QtyTerjual: report.TopByQty[0].QtyTerjual}
+	}
+
+	report.ZeroSales, err = r.getZeroSales(start, end, outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
 }
 
-func (r *ReportRepository) GetReportByDateRange(startDate, endDate string) (*models.Report, error) {
-	var report models.Report
+// queryer *sql.DB atau *sql.Tx, supaya agregat laporan bisa dipakai di dalam transaksi (Z report)
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// salesRange rentang laporan yang dipecah: hari bisnis yang sudah lewat dibaca dari ringkasan harian
+// [histFrom, histTo), sisanya (hari ini atau rentang yang tidak pas tengah malam) langsung dari transaksi [liveStart, liveEnd).
+// Di query selalu jadi $1 sampai $4, $5 outlet
+type salesRange struct {
+	histFrom, histTo   string
+	liveStart, liveEnd time.Time
+}
+
+// splitRange pecah [start, end) jadi bagian ringkasan harian dan bagian live. Ringkasan hanya dipakai untuk
+// hari penuh sebelum hari ini, allLive true berarti semua langsung dari transaksi
+func (r *ReportRepository) splitRange(start, end time.Time, allLive bool) salesRange {
+	now := time.Now().In(r.loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, r.loc)
+	histEnd := end
+	if today.Before(histEnd) {
+		histEnd = today
+	}
+
+	if allLive || !start.Before(histEnd) || !isMidnight(start.In(r.loc)) || !isMidnight(histEnd.In(r.loc)) {
+		return salesRange{histFrom: "1970-01-01", histTo: "1970-01-01", liveStart: start, liveEnd: end}
+	}
+	return salesRange{
+		histFrom:  start.In(r.loc).Format("2006-01-02"),
+		histTo:    histEnd.In(r.loc).Format("2006-01-02"),
+		liveStart: histEnd,
+		liveEnd:   end,
+	}
+}
+
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
 
-	// Get total revenue and transaction count
+// args parameter $1 sampai $5 lalu parameter tambahan query mulai $6
+func (s salesRange) args(outletID int, extra ...interface{}) []interface{} {
+	return append([]interface{}{s.histFrom, s.histTo, s.liveStart, s.liveEnd, outletID}, extra...)
+}
+
+// item terjual per produk dari ringkasan harian digabung transaksi live, dipakai dengan salesRange.args
+const itemSales = `(
+		SELECT s.product_id, s.qty AS quantity, s.revenue AS subtotal
+		FROM daily_sales_summary s
+		WHERE s.business_date >= $1::date AND s.business_date < $2::date AND ($5 = 0 OR s.outlet_id = $5)
+		UNION ALL
+		SELECT td.product_id, td.quantity, td.subtotal
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE t.created_at >= $3 AND t.created_at < $4 AND ($5 = 0 OR t.outlet_id = $5)
+	) td`
+
+// GetTotals total omzet dan jumlah transaksi dalam [start, end), hari yang sudah lewat dari ringkasan harian
+func (r *ReportRepository) GetTotals(start, end time.Time, outletID int) (int, int, error) {
+	var revenue, count int
 	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(revenue), 0), COALESCE(SUM(tx_count), 0)
+		FROM (
+			SELECT revenue, transaction_count AS tx_count
+			FROM daily_sales_totals
+			WHERE business_date >= $1::date AND business_date < $2::date AND ($5 = 0 OR outlet_id = $5)
+			UNION ALL
+			SELECT total_amount, 1
+			FROM transactions
+			WHERE created_at >= $3 AND created_at < $4 AND ($5 = 0 OR outlet_id = $5)
+		) s
+	`, r.splitRange(start, end, false).args(outletID)...).Scan(&revenue, &count)
+	return revenue, count, err
+}
+
+// salesTotals total omzet dan jumlah transaksi langsung dari transaksi, untuk Z report di dalam transaksi database
+func salesTotals(q queryer, start, end time.Time, outletID int) (int, int, error) {
+	var revenue, count int
+	err := q.QueryRow(`
 		SELECT COALESCE(SUM(total_amount), 0), COUNT(*)
 		FROM transactions
-		WHERE DATE(created_at) BETWEEN $1 AND $2
-	`, startDate, endDate).Scan(&report.TotalRevenue, &report.TotalTransaction)
+		WHERE created_at >= $1 AND created_at < $2 AND ($3 = 0 OR outlet_id = $3)
+	`, start, end, outletID).Scan(&revenue, &count)
+	return revenue, count, err
+}
+
+// getProductSales qty dan omzet setiap produk yang terjual dalam periode
+func (r *ReportRepository) getProductSales(start, end time.Time, outletID int) ([]models.ProductSales, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, SUM(td.quantity), SUM(td.subtotal)
+		FROM `+itemSales+`
+		JOIN product p ON td.product_id = p.id
+		GROUP BY p.id, p.name
+		ORDER BY p.name
+	`, r.splitRange(start, end, false).args(outletID)...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	// Get best selling product
-	err = r.db.QueryRow(`
-		SELECT p.name, COALESCE(SUM(td.quantity), 0)
-		FROM transaction_details td
+	sales := make([]models.ProductSales, 0)
+	for rows.Next() {
+		var s models.ProductSales
+		err := rows.Scan(&s.ProductID, &s.Nama, &s.QtyTerjual, &s.Revenue)
+		if err != nil {
+			return nil, err
+		}
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
+
+// getZeroSales produk aktif tanpa penjualan sama sekali dalam periode
+func (r *ReportRepository) getZeroSales(start, end time.Time, outletID int) ([]models.ProductSales, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name
+		FROM product p
+		WHERE p.archived = FALSE AND NOT EXISTS (
+			SELECT 1 FROM `+itemSales+`
+			WHERE td.product_id = p.id
+		)
+		ORDER BY p.name
+	`, r.splitRange(start, end, false).args(outletID)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.ProductSales, 0)
+	for rows.Next() {
+		s := models.ProductSales{Rank: len(result) + 1}
+		err := rows.Scan(&s.ProductID, &s.Nama)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// rankProducts urutkan salinan sales dengan less, ambil n teratas dan beri nomor peringkat.
+// Nilai yang sama diurutkan nama produk
+func rankProducts(sales []models.ProductSales, n int, less func(a, b models.ProductSales) bool) []models.ProductSales {
+	ranked := make([]models.ProductSales, len(sales))
+	copy(ranked, sales)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return less(ranked[i], ranked[j])
+	})
+
+	if n < len(ranked) {
+		ranked = ranked[:n]
+	}
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+	return ranked
+}
+
+// GetSalesSeries penjualan per periode groupBy (hour, day, week, month) untuk transaksi dalam [start, end).
+// Periode dihitung di zona waktu toko, periode kosong diisi 0 lewat generate_series.
+// Per jam selalu dari transaksi karena ringkasan harian tidak menyimpan jam
+func (r *ReportRepository) GetSalesSeries(start, end time.Time, groupBy string, outletID int) ([]models.SalesBucket, error) {
+	rng := r.splitRange(start, end, groupBy == "hour")
+	rows, err := r.db.Query(`
+		WITH buckets AS (
+			SELECT generate_series(
+				date_trunc($6, $8::timestamptz AT TIME ZONE $7),
+				date_trunc($6, ($9::timestamptz - interval '1 second') AT TIME ZONE $7),
+				('1 ' || $6)::interval
+			) AS bucket
+		),
+		sales AS (
+			SELECT date_trunc($6, local_time) AS bucket, SUM(tx_count) AS tx_count, SUM(revenue) AS revenue, SUM(qty) AS qty
+			FROM (
+				SELECT business_date::timestamp AS local_time, transaction_count AS tx_count, revenue, items_sold AS qty
+				FROM daily_sales_totals
+				WHERE business_date >= $1::date AND business_date < $2::date AND ($5 = 0 OR outlet_id = $5)
+				UNION ALL
+				SELECT t.created_at AT TIME ZONE $7, 1, t.total_amount,
+				       (SELECT COALESCE(SUM(td.quantity), 0) FROM transaction_details td WHERE td.transaction_id = t.id)
+				FROM transactions t
+				WHERE t.created_at >= $3 AND t.created_at < $4 AND ($5 = 0 OR t.outlet_id = $5)
+			) s
+			GROUP BY 1
+		)
+		SELECT b.bucket AT TIME ZONE $7, COALESCE(s.revenue, 0), COALESCE(s.tx_count, 0), COALESCE(s.qty, 0)
+		FROM buckets b
+		LEFT JOIN sales s ON s.bucket = b.bucket
+		ORDER BY b.bucket
+	`, rng.args(outletID, groupBy, r.loc.String(), start, end)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make([]models.SalesBucket, 0)
+	for rows.Next() {
+		var b models.SalesBucket
+		err := rows.Scan(&b.Period, &b.Revenue, &b.TransactionCount, &b.ItemsSold)
+		if err != nil {
+			return nil, err
+		}
+		if b.TransactionCount > 0 {
+			b.AverageBasket = b.Revenue / b.TransactionCount
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+// GetCategoryRollup total penjualan per kategori di bawah parentID (0 = kategori paling atas),
+// penjualan sub kategori dijumlahkan ke kategori induknya di level tersebut
+func (r *ReportRepository) GetCategoryRollup(start, end time.Time, parentID, outletID int) ([]models.CategoryRollup, error) {
+	rows, err := r.db.Query(`
+		WITH RECURSIVE tree AS (
+			SELECT id, id AS root_id FROM category
+			WHERE parent_id IS NOT DISTINCT FROM NULLIF($6::int, 0)
+			UNION ALL
+			SELECT c.id, t.root_id FROM category c JOIN tree t ON c.parent_id = t.id
+		),
+		sales AS (
+			SELECT p.category_id, SUM(td.subtotal) AS revenue, SUM(td.quantity) AS qty
+			FROM `+itemSales+`
+			JOIN product p ON td.product_id = p.id
+			GROUP BY p.category_id
+		)
+		SELECT c.id, c.name, COALESCE(SUM(s.revenue), 0), COALESCE(SUM(s.qty), 0)
+		FROM tree
+		JOIN category c ON c.id = tree.root_id
+		LEFT JOIN sales s ON s.category_id = tree.id
+		GROUP BY c.id, c.name
+		ORDER BY 3 DESC, c.name
+	`, r.splitRange(start, end, false).args(outletID, parentID)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.CategoryRollup, 0)
+	for rows.Next() {
+		var c models.CategoryRollup
+		err := rows.Scan(&c.CategoryID, &c.Nama, &c.TotalRevenue, &c.QtyTerjual)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+// kolom pengelompokan per dimensi breakdown: id, nama, id kategori, nama kategori
+var breakdownColumns = map[string][4]string{
+	models.BreakdownByCategory: {"COALESCE(p.category_id, 0)", "COALESCE(c.name, 'Tanpa Kategori')", "0", "''"},
+	models.BreakdownByProduct:  {"p.id", "p.name", "COALESCE(p.category_id, 0)", "COALESCE(c.name, '')"},
+}
+
+// kolom urutan breakdown, dipetakan dari nilai sort yang sudah divalidasi
+var breakdownSort = map[string]string{
+	"revenue": "revenue",
+	"qty":     "qty",
+	"name":    "2",
+}
+
+// GetSalesBreakdown penjualan per kategori atau per produk dari transaction_details dalam [start, end),
+// satu halaman sesuai q. Sort, Order dan By harus sudah divalidasi service
+func (r *ReportRepository) GetSalesBreakdown(q models.BreakdownQuery, start, end time.Time) (*models.SalesBreakdown, error) {
+	cols := breakdownColumns[q.By]
+	rng := r.splitRange(start, end, false)
+	from := `
+		FROM ` + itemSales + `
 		JOIN product p ON td.product_id = p.id
-		JOIN transactions t ON td.transaction_id = t.id
-		WHERE DATE(t.created_at) BETWEEN $1 AND $2
-		GROUP BY p.id, p.name
-		ORDER BY SUM(td.quantity) DESC
-		LIMIT 1
-	`, startDate, endDate).Scan(&report.ProdukTerlaris.Nama, &report.ProdukTerlaris.QtyTerjual)
-	if err != nil && err != sql.ErrNoRows {
+		LEFT JOIN category c ON p.category_id = c.id`
+
+	result := models.SalesBreakdown{
+		By:       q.By,
+		Sort:     q.Sort,
+		Order:    q.Order,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}
+
+	err := r.db.QueryRow(`
+		SELECT COUNT(DISTINCT `+cols[0]+`), COALESCE(SUM(td.quantity), 0), COALESCE(SUM(td.subtotal), 0)`+from,
+		rng.args(q.OutletID)...).Scan(&result.TotalRows, &result.TotalQty, &result.TotalRevenue)
+	if err != nil {
 		return nil, err
 	}
 
-	return &report, nil
+	// LIMIT NULL berarti tanpa batas
+	var limit interface{} = q.PageSize
+	offset := (q.Page - 1) * q.PageSize
+	if q.All {
+		limit, offset = nil, 0
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+cols[0]+`, `+cols[1]+`, `+cols[2]+`, `+cols[3]+`,
+		       SUM(td.quantity) AS qty, SUM(td.subtotal) AS revenue`+from+`
+		GROUP BY 1, 2, 3, 4
+		ORDER BY `+breakdownSort[q.Sort]+` `+q.Order+`, 2, 1
+		LIMIT $6 OFFSET $7
+	`, rng.args(q.OutletID, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result.Rows = make([]models.BreakdownRow, 0)
+	for rows.Next() {
+		var b models.BreakdownRow
+		err := rows.Scan(&b.ID, &b.Nama, &b.CategoryID, &b.CategoryName, &b.QtyTerjual, &b.Revenue)
+		if err != nil {
+			return nil, err
+		}
+		b.QtyShare = sharePercent(b.QtyTerjual, result.TotalQty)
+		b.RevenueShare = sharePercent(b.Revenue, result.TotalRevenue)
+		result.Rows = append(result.Rows, b)
+	}
+	return &result, rows.Err()
+}
+
+// sharePercent persentase part dari total, dibulatkan 2 angka desimal
+func sharePercent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
+
+// GetInventory stok semua produk aktif beserta qty terjual dalam [start, end) dan hari bisnis penjualan terakhir,
+// keduanya dari ringkasan harian. start dan end tengah malam zona waktu toko.
+// outletID 0 memakai stok total produk, selain itu stok dan penjualan outlet tersebut
+func (r *ReportRepository) GetInventory(start, end time.Time, outletID int) ([]models.InventoryRow, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, COALESCE(p.category_id, 0), COALESCE(c.name, ''),
+		       p.price, p.cost,
+		       CASE WHEN $3 = 0 THEN p.stock ELSE COALESCE(os.stock, 0) END,
+		       COALESCE(s.qty, 0), to_char(s.last_sold, 'YYYY-MM-DD')
+		FROM product p
+		LEFT JOIN category c ON p.category_id = c.id
+		LEFT JOIN outlet_stock os ON os.product_id = p.id AND os.outlet_id = $3
+		LEFT JOIN (
+			SELECT product_id,
+			       SUM(qty) FILTER (WHERE business_date >= $1::date AND business_date < $2::date) AS qty,
+			       MAX(business_date) AS last_sold
+			FROM daily_sales_summary
+			WHERE $3 = 0 OR outlet_id = $3
+			GROUP BY product_id
+		) s ON s.product_id = p.id
+		WHERE p.archived = FALSE
+		ORDER BY p.name, p.id
+	`, start.In(r.loc).Format("2006-01-02"), end.In(r.loc).Format("2006-01-02"), outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.InventoryRow, 0)
+	for rows.Next() {
+		var i models.InventoryRow
+		err := rows.Scan(&i.ID, &i.Nama, &i.CategoryID, &i.CategoryName, &i.Price, &i.Cost, &i.Stock, &i.QtyTerjual, &i.LastSoldDate)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
 }
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitRange(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &ReportRepository{loc: loc}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	day := func(offset int) time.Time { return today.AddDate(0, 0, offset) }
+	date := func(offset int) string { return day(offset).Format("2006-01-02") }
+
+	tests := []struct {
+		name             string
+		start, end       time.Time
+		allLive          bool
+		histFrom, histTo string
+		liveStart        time.Time
+	}{
+		{name: "hanya hari ini", start: day(0), end: day(1), histFrom: "1970-01-01", histTo: "1970-01-01", liveStart: day(0)},
+		{name: "masa lalu penuh", start: day(-7), end: day(-1), histFrom: date(-7), histTo: date(-1), liveStart: day(-1)},
+		{name: "masa lalu sampai hari ini", start: day(-7), end: day(1), histFrom: date(-7), histTo: date(0), liveStart: day(0)},
+		{name: "allLive", start: day(-7), end: day(1), allLive: true, histFrom: "1970-01-01", histTo: "1970-01-01", liveStart: day(-7)},
+		{name: "start bukan tengah malam", start: day(-7).Add(time.Hour), end: day(1), histFrom: "1970-01-01", histTo: "1970-01-01", liveStart: day(-7).Add(time.Hour)},
+		{name: "end bukan tengah malam", start: day(-7), end: day(-2).Add(30 * time.Minute), histFrom: "1970-01-01", histTo: "1970-01-01", liveStart: day(-7)},
+		{name: "masa depan", start: day(1), end: day(3), histFrom: "1970-01-01", histTo: "1970-01-01", liveStart: day(1)},
+		{name: "start dalam zona lain", start: day(-3).UTC(), end: day(1).UTC(), histFrom: date(-3), histTo: date(0), liveStart: day(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.splitRange(tt.start, tt.end, tt.allLive)
+			if got.histFrom != tt.histFrom || got.histTo != tt.histTo {
+				t.Errorf("hist = [%s, %s), want [%s, %s)", got.histFrom, got.histTo, tt.histFrom, tt.histTo)
+			}
+			if !got.liveStart.Equal(tt.liveStart) {
+				t.Errorf("liveStart = %s, want %s", got.liveStart, tt.liveStart)
+			}
+			if !got.liveEnd.Equal(tt.end) {
+				t.Errorf("liveEnd = %s, want %s", got.liveEnd, tt.end)
+			}
+		})
+	}
+}
+
+func TestSalesRangeArgs(t *testing.T) {
+	start := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+	s := salesRange{histFrom: "2026-10-01", histTo: "2026-10-14", liveStart: start, liveEnd: end}
+
+	args := s.args(3, 10, "x")
+	if len(args) != 7 {
+		t.Fatalf("len(args) = %d, want 7", len(args))
+	}
+	if args[0] != "2026-10-01" || args[1] != "2026-10-14" || args[2] != start || args[3] != end || args[4] != 3 || args[5] != 10 || args[6] != "x" {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestIsMidnight(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	midnight := time.Date(2026, 10, 14, 0, 0, 0, 0, loc)
+	if !isMidnight(midnight) {
+		t.Error("isMidnight(00:00) = false")
+	}
+	if isMidnight(midnight.Add(time.Nanosecond)) {
+		t.Error("isMidnight(00:00:00.000000001) = true")
+	}
+	// tengah malam Jakarta adalah 17:00 UTC
+	if isMidnight(midnight.UTC()) {
+		t.Error("isMidnight(17:00 UTC) = true")
+	}
+}
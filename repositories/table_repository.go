@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+)
+
+type TableRepository struct {
+	db *sql.DB
+}
+
+func NewTableRepository(db *sql.DB) *TableRepository {
+	return &TableRepository{db: db}
+}
+
+// tableSelect sekalian ambil order terbuka (draft / parkir) di meja
+const tableSelect = `
+	SELECT t.id, t.name, t.seats, t.archived, t.archived_at, t.created_at,
+	       (SELECT o.id FROM orders o WHERE o.table_id = t.id AND o.status IN ('draft', 'parked') LIMIT 1)
+	FROM dining_tables t`
+
+func scanTable(row interface{ Scan(...interface{}) error }, t *models.DiningTable) error {
+	return row.Scan(&t.ID, &t.Name, &t.Seats, &t.Archived, &t.ArchivedAt, &t.CreatedAt, &t.OpenOrderID)
+}
+
+func (repo *TableRepository) GetAll(includeArchived bool) ([]models.DiningTable, error) {
+	query := tableSelect
+	if !includeArchived {
+		query += " WHERE t.archived = FALSE"
+	}
+	query += " ORDER BY t.name"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]models.DiningTable, 0)
+	for rows.Next() {
+		var t models.DiningTable
+		err := scanTable(rows, &t)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+func (repo *TableRepository) GetByID(id int) (*models.DiningTable, error) {
+	var t models.DiningTable
+	err := scanTable(repo.db.QueryRow(tableSelect+" WHERE t.id = $1", id), &t)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Meja tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (repo *TableRepository) Create(table *models.DiningTable) error {
+	query := "INSERT INTO dining_tables (name, seats) VALUES ($1, $2) RETURNING id, created_at"
+	return repo.db.QueryRow(query, table.Name, table.Seats).Scan(&table.ID, &table.CreatedAt)
+}
+
+func (repo *TableRepository) Update(table *models.DiningTable) error {
+	result, err := repo.db.Exec("UPDATE dining_tables SET name = $1, seats = $2 WHERE id = $3", table.Name, table.Seats, table.ID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperrors.NotFound("Meja tidak ditemukan")
+	}
+	return nil
+}
+
+// Delete archive meja, ditolak kalau meja masih punya order terbuka
+func (repo *TableRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var archived bool
+	err = tx.QueryRow("SELECT archived FROM dining_tables WHERE id = $1 FOR UPDATE", id).Scan(&archived)
+	if err == sql.ErrNoRows || (err == nil && archived) {
+		return apperrors.NotFound("Meja tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+
+	var openOrderID int
+	err = tx.QueryRow("SELECT id FROM orders WHERE table_id = $1 AND status IN ($2, $3) LIMIT 1",
+		id, models.OrderDraft, models.OrderParked).Scan(&openOrderID)
+	if err == nil {
+		return apperrors.Conflict(fmt.Sprintf("Meja masih dipakai order #%d", openOrderID))
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE dining_tables SET archived = TRUE, archived_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Restore meja yang sudah di-archive
+func (repo *TableRepository) Restore(id int) error {
+	result, err := repo.db.Exec("UPDATE dining_tables SET archived = FALSE, archived_at = NULL WHERE id = $1 AND archived = TRUE", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperrors.NotFound("Meja tidak ditemukan atau tidak di-archive")
+	}
+	return nil
+}
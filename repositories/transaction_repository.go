@@ -3,78 +3,315 @@ package repositories
 import (
 	"database/sql"
 	"fmt"
+	"kasir-api/apperrors"
 	"kasir-api/models"
+	"sort"
+	"time"
 )
 
 type TransactionRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	loc *time.Location // zona waktu toko untuk ringkasan penjualan harian
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+func NewTransactionRepository(db *sql.DB, loc *time.Location) *TransactionRepository {
+	return &TransactionRepository{db: db, loc: loc}
 }
 
 // Add transaction-related methods
-func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+func (repo *TransactionRepository) CreateTransaction(req models.CheckoutRequest, loyalty models.LoyaltyConfig) (*models.Transaction, error) {
 	tx, err := repo.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	transaction, err := createTransactionTx(tx, req, loyalty, repo.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// createTransactionTx logic checkout di dalam transaksi database yang sudah dibuka,
+// dipakai juga oleh checkout order supaya aturannya sama persis. loc zona waktu toko untuk ringkasan harian
+func createTransactionTx(tx *sql.Tx, req models.CheckoutRequest, loyalty models.LoyaltyConfig, loc *time.Location) (*models.Transaction, error) {
+	var transaction models.Transaction
+	var pointsBalance int
+	priceListCode := req.PriceList
+
+	if req.CustomerID > 0 {
+		var customerPriceList string
+		err := tx.QueryRow(`
+			SELECT c.points_balance, COALESCE(pl.code, '')
+			FROM customer c LEFT JOIN price_list pl ON c.price_list_id = pl.id
+			WHERE c.id = $1
+			FOR UPDATE OF c
+		`, req.CustomerID).Scan(&pointsBalance, &customerPriceList)
+		if err == sql.ErrNoRows {
+			return nil, apperrors.Validation(apperrors.CodeInvalidReference, "customer_id", fmt.Sprintf("customer id %d not found", req.CustomerID))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if req.RedeemPoints > pointsBalance {
+			return nil, apperrors.Validation(apperrors.CodeInvalid, "redeem_points", fmt.Sprintf("poin tidak cukup (saldo %d)", pointsBalance))
+		}
+
+		transaction.CustomerID = &req.CustomerID
+		if priceListCode == "" {
+			priceListCode = customerPriceList
+		}
+	}
+	if priceListCode == "" {
+		priceListCode = RetailPriceList
+	}
+
+	outletID, err := resolveOutletTx(tx, req.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkBusinessDayOpenTx(tx, outletID)
+	if err != nil {
+		return nil, err
+	}
+	transaction.OutletID = outletID
+
+	transaction.PaymentMethod = req.PaymentMethod
+	if transaction.PaymentMethod == "" {
+		transaction.PaymentMethod = models.PaymentCash
+	}
+
+	var priceListID, retailID int
+	err = tx.QueryRow("SELECT id FROM price_list WHERE code = $1", priceListCode).Scan(&priceListID)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.Validation(apperrors.CodeInvalidReference, "price_list", fmt.Sprintf("price list %s not found", priceListCode))
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow("SELECT id FROM price_list WHERE code = $1", RetailPriceList).Scan(&retailID)
+	if err != nil {
+		return nil, err
+	}
+
 	totalAmount := 0
 	details := make([]models.TransactionDetail, 0)
-	var transactionID int
+	detailPriceListIDs := make([]int, 0)
+
+	// baris product dan outlet_stock dikunci urut product_id supaya checkout bersamaan tidak saling deadlock
+	items := make([]models.CheckoutItem, len(req.Items))
+	copy(items, req.Items)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ProductID < items[j].ProductID })
 
 	for _, item := range items {
-		var productPrice, stock int
+		var productPrice int
 		var productName string
+		var archived bool
 
-		err := tx.QueryRow("SELECT name, price, stock FROM product WHERE id = $1", item.ProductID).Scan(&productName, &productPrice, &stock)
+		err := tx.QueryRow("SELECT name, price, archived FROM product WHERE id = $1 FOR UPDATE", item.ProductID).Scan(&productName, &productPrice, &archived)
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+			return nil, apperrors.NotFound(fmt.Sprintf("product id %d not found", item.ProductID))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if archived {
+			return nil, apperrors.Validation(apperrors.CodeInvalidReference, "product_id", fmt.Sprintf("product id %d is archived", item.ProductID))
 		}
+		// stok outlet yang sedang di-reserve order lain tidak boleh ikut terjual
+		stock, err := lockOutletStock(tx, outletID, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if stock < item.Quantity {
+			return nil, apperrors.InsufficientStock("quantity", fmt.Sprintf("stok %s tidak cukup (sisa %d)", productName, stock))
+		}
+
+		unitPrice, usedPriceListID, err := unitPriceFor(tx, item, productPrice, priceListID, retailID)
 		if err != nil {
 			return nil, err
 		}
 
-		subtotal := productPrice * item.Quantity
+		subtotal := unitPrice * item.Quantity
 		totalAmount += subtotal
 
-		_, err = tx.Exec("UPDATE product set stock = stock - $1 WHERE id = $2", item.Quantity, item.ProductID)
+		err = adjustOutletStock(tx, outletID, item.ProductID, -item.Quantity)
 		if err != nil {
 			return nil, err
 		}
 
+		usedPriceList := RetailPriceList
+		if usedPriceListID == priceListID {
+			usedPriceList = priceListCode
+		}
+
 		details = append(details, models.TransactionDetail{
 			ProductID:   item.ProductID,
 			ProductName: productName,
 			Quantity:    item.Quantity,
+			UnitPrice:   unitPrice,
+			PriceList:   usedPriceList,
 			Subtotal:    subtotal,
 		})
+		detailPriceListIDs = append(detailPriceListIDs, usedPriceListID)
+	}
+
+	// poin yang ditukar jadi pembayaran, poin baru dihitung dari sisa yang dibayar
+	amountDue := totalAmount - req.RedeemPoints*loyalty.PointValue
+	if amountDue < 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "redeem_points", "nilai poin melebihi total belanja")
+	}
+	pointsEarned := 0
+	if transaction.CustomerID != nil && loyalty.EarnPer > 0 {
+		pointsEarned = amountDue / loyalty.EarnPer
+	}
+
+	if req.OrderID > 0 {
+		transaction.OrderID = &req.OrderID
 	}
 
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", totalAmount).Scan(&transactionID)
+	err = tx.QueryRow(`INSERT INTO transactions (total_amount, price_list_id, customer_id, points_redeemed, points_earned, amount_due, order_id, outlet_id, payment_method)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`,
+		totalAmount, priceListID, transaction.CustomerID, req.RedeemPoints, pointsEarned, amountDue, transaction.OrderID, outletID, transaction.PaymentMethod).Scan(&transaction.ID, &transaction.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 
+	if transaction.CustomerID != nil {
+		err = recordLoyalty(tx, req.CustomerID, transaction.ID, pointsBalance, req.RedeemPoints, pointsEarned)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	for i := range details {
-		details[i].TransactionID = transactionID
-		_, err = tx.Exec("INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4)",
-			details[i].TransactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal)
+		details[i].TransactionID = transaction.ID
+		err = tx.QueryRow(`INSERT INTO transaction_details (transaction_id, product_id, quantity, unit_price, price_list_id, subtotal)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			details[i].TransactionID, details[i].ProductID, details[i].Quantity, details[i].UnitPrice, detailPriceListIDs[i], details[i].Subtotal).Scan(&details[i].ID)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	transaction.TotalAmount = totalAmount
+	transaction.PriceList = priceListCode
+	transaction.PointsRedeemed = req.RedeemPoints
+	transaction.PointsEarned = pointsEarned
+	transaction.AmountDue = amountDue
+	transaction.Details = details
+
+	err = recordDailySalesTx(tx, &transaction, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// recordLoyalty catat penukaran dan perolehan poin di ledger lalu update saldo customer
+func recordLoyalty(tx *sql.Tx, customerID, transactionID, balance, redeemed, earned int) error {
+	entries := []struct {
+		points int
+		reason string
+	}{
+		{-redeemed, "redeem"},
+		{earned, "earn"},
+	}
+
+	for _, e := range entries {
+		if e.points == 0 {
+			continue
+		}
+		balance += e.points
+		_, err := tx.Exec(`INSERT INTO loyalty_ledger (customer_id, transaction_id, points, reason, balance_after)
+			VALUES ($1, $2, $3, $4, $5)`, customerID, transactionID, e.points, e.reason, balance)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec("UPDATE customer SET points_balance = $1 WHERE id = $2", balance, customerID)
+	return err
+}
+
+// GetByCustomer histori belanja customer beserta detailnya, terbaru di atas
+func (repo *TransactionRepository) GetByCustomer(customerID int) ([]models.Transaction, error) {
+	rows, err := repo.db.Query(`
+		SELECT t.id, t.total_amount, COALESCE(pl.code, ''), t.customer_id, COALESCE(t.outlet_id, 0), t.points_redeemed, t.points_earned,
+		       COALESCE(t.amount_due, t.total_amount), t.payment_method, t.created_at
+		FROM transactions t
+		LEFT JOIN price_list pl ON t.price_list_id = pl.id
+		WHERE t.customer_id = $1
+		ORDER BY t.created_at DESC, t.id DESC
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var t models.Transaction
+		err := rows.Scan(&t.ID, &t.TotalAmount, &t.PriceList, &t.CustomerID, &t.OutletID, &t.PointsRedeemed, &t.PointsEarned, &t.AmountDue, &t.PaymentMethod, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		t.Details = make([]models.TransactionDetail, 0)
+		index[t.ID] = len(transactions)
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	detailRows, err := repo.db.Query(`
+		SELECT td.id, td.transaction_id, td.product_id, p.name, td.quantity,
+		       COALESCE(td.unit_price, td.subtotal / NULLIF(td.quantity, 0), 0), COALESCE(pl.code, ''), td.subtotal
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		JOIN product p ON td.product_id = p.id
+		LEFT JOIN price_list pl ON td.price_list_id = pl.id
+		WHERE t.customer_id = $1
+		ORDER BY td.id
+	`, customerID)
+	if err != nil {
 		return nil, err
 	}
+	defer detailRows.Close()
+
+	for detailRows.Next() {
+		var d models.TransactionDetail
+		err := detailRows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.Quantity, &d.UnitPrice, &d.PriceList, &d.Subtotal)
+		if err != nil {
+			return nil, err
+		}
+		i := index[d.TransactionID]
+		transactions[i].Details = append(transactions[i].Details, d)
+	}
+	return transactions, nil
+}
 
-	return &models.Transaction{
-		ID:          transactionID,
-		Details:     details,
-		TotalAmount: totalAmount,
-	}, nil
+// unitPriceFor cari harga satuan: tier di daftar harga yang diminta dengan min_qty terbesar
+// yang terpenuhi, kalau tidak ada pakai tier retail, terakhir harga dasar produk
+func unitPriceFor(tx *sql.Tx, item models.CheckoutItem, basePrice, priceListID, retailID int) (int, int, error) {
+	var price, usedPriceListID int
+	err := tx.QueryRow(`
+		SELECT price, price_list_id FROM product_price
+		WHERE product_id = $1 AND min_qty <= $2 AND price_list_id IN ($3, $4)
+		ORDER BY (price_list_id = $3) DESC, min_qty DESC
+		LIMIT 1
+	`, item.ProductID, item.Quantity, priceListID, retailID).Scan(&price, &usedPriceListID)
+	if err == sql.ErrNoRows {
+		return basePrice, retailID, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return price, usedPriceListID, nil
 }
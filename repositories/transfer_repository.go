@@ -0,0 +1,473 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type TransferRepository struct {
+	db *sql.DB
+}
+
+func NewTransferRepository(db *sql.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+const transferSelect = `
+	SELECT st.id, st.from_outlet_id, fo.name, st.to_outlet_id, tou.name, st.status, st.note, st.receive_note,
+	       st.shortage_action, st.created_at, st.shipped_at, st.received_at
+	FROM stock_transfer st
+	JOIN outlet fo ON st.from_outlet_id = fo.id
+	JOIN outlet tou ON st.to_outlet_id = tou.id`
+
+func scanTransfer(row interface{ Scan(...interface{}) error }, t *models.StockTransfer) error {
+	return row.Scan(&t.ID, &t.FromOutletID, &t.FromOutletName, &t.ToOutletID, &t.ToOutletName, &t.Status, &t.Note, &t.ReceiveNote,
+		&t.ShortageAction, &t.CreatedAt, &t.ShippedAt, &t.ReceivedAt)
+}
+
+// GetAll daftar transfer, outletID > 0 untuk transfer yang keluar atau masuk ke outlet tersebut
+func (repo *TransferRepository) GetAll(status string, outletID int) ([]models.StockTransfer, error) {
+	query := transferSelect
+	conditions := []string{}
+	args := []interface{}{}
+	if status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("st.status = $%d", len(args)))
+	}
+	if outletID > 0 {
+		args = append(args, outletID)
+		conditions = append(conditions, fmt.Sprintf("(st.from_outlet_id = $%d OR st.to_outlet_id = $%d)", len(args), len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY st.created_at DESC, st.id DESC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := make([]models.StockTransfer, 0)
+	for rows.Next() {
+		var t models.StockTransfer
+		err := scanTransfer(rows, &t)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = repo.loadItems(transfers)
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (repo *TransferRepository) GetByID(id int) (*models.StockTransfer, error) {
+	var t models.StockTransfer
+	err := scanTransfer(repo.db.QueryRow(transferSelect+" WHERE st.id = $1", id), &t)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Transfer stok tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := []models.StockTransfer{t}
+	err = repo.loadItems(transfers)
+	if err != nil {
+		return nil, err
+	}
+	return &transfers[0], nil
+}
+
+// loadItems isi baris produk dan selisih penerimaan semua transfer sekaligus
+func (repo *TransferRepository) loadItems(transfers []models.StockTransfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(transfers))
+	index := make(map[int]int, len(transfers))
+	for i := range transfers {
+		ids[i] = int64(transfers[i].ID)
+		index[transfers[i].ID] = i
+		transfers[i].Items = make([]models.StockTransferItem, 0)
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT sti.transfer_id, sti.id, sti.product_id, p.name, sti.quantity, sti.received_quantity
+		FROM stock_transfer_items sti
+		JOIN product p ON sti.product_id = p.id
+		WHERE sti.transfer_id = ANY($1)
+		ORDER BY sti.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transferID int
+		var item models.StockTransferItem
+		err := rows.Scan(&transferID, &item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.ReceivedQuantity)
+		if err != nil {
+			return err
+		}
+		if item.ReceivedQuantity != nil {
+			item.Discrepancy = *item.ReceivedQuantity - item.Quantity
+		}
+
+		t := &transfers[index[transferID]]
+		t.Items = append(t.Items, item)
+		if item.Discrepancy != 0 {
+			t.HasDiscrepancy = true
+		}
+	}
+	return rows.Err()
+}
+
+// Create buat dokumen transfer draft
+func (repo *TransferRepository) Create(req models.StockTransferRequest) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = checkTransferOutletsTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow("INSERT INTO stock_transfer (from_outlet_id, to_outlet_id, note) VALUES ($1, $2, $3) RETURNING id",
+		req.FromOutletID, req.ToOutletID, req.Note).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTransferItemsTx(tx, id, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Update ganti outlet, catatan dan semua baris produk, hanya selama masih draft
+func (repo *TransferRepository) Update(id int, req models.StockTransferRequest) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = lockTransferTx(tx, id, models.TransferDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkTransferOutletsTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfer SET from_outlet_id = $1, to_outlet_id = $2, note = $3 WHERE id = $4",
+		req.FromOutletID, req.ToOutletID, req.Note, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM stock_transfer_items WHERE transfer_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	err = insertTransferItemsTx(tx, id, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+func checkTransferOutletsTx(tx *sql.Tx, req models.StockTransferRequest) error {
+	err := checkOutletTx(tx, req.FromOutletID)
+	if err != nil {
+		return err
+	}
+	return checkOutletTx(tx, req.ToOutletID)
+}
+
+// insertTransferItemsTx produk yang sama di beberapa baris digabung
+func insertTransferItemsTx(tx *sql.Tx, transferID int, items []models.CheckoutItem) error {
+	for _, item := range items {
+		var archived bool
+		err := tx.QueryRow("SELECT archived FROM product WHERE id = $1", item.ProductID).Scan(&archived)
+		if err == sql.ErrNoRows || (err == nil && archived) {
+			return apperrors.Validation(apperrors.CodeInvalidReference, "product_id", fmt.Sprintf("product id %d not found", item.ProductID))
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO stock_transfer_items (transfer_id, product_id, quantity) VALUES ($1, $2, $3)
+			ON CONFLICT (transfer_id, product_id) DO UPDATE SET quantity = stock_transfer_items.quantity + EXCLUDED.quantity
+		`, transferID, item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockTransferTx kunci transfer dan pastikan statusnya sesuai langkah yang diminta
+func lockTransferTx(tx *sql.Tx, id int, expectedStatus string) (*models.StockTransfer, error) {
+	var t models.StockTransfer
+	err := tx.QueryRow("SELECT id, from_outlet_id, to_outlet_id, status FROM stock_transfer WHERE id = $1 FOR UPDATE", id).
+		Scan(&t.ID, &t.FromOutletID, &t.ToOutletID, &t.Status)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Transfer stok tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != expectedStatus {
+		return nil, apperrors.Conflict(fmt.Sprintf("Transfer berstatus %s, harus %s", t.Status, expectedStatus))
+	}
+	return &t, nil
+}
+
+// lockActiveOutletsTx kunci outlet (urut id, FOR SHARE) supaya tidak diarsipkan selama transaksi berjalan,
+// 409 kalau ada yang sudah diarsipkan
+func lockActiveOutletsTx(tx *sql.Tx, outletIDs ...int) error {
+	ids := make([]int64, len(outletIDs))
+	for i, id := range outletIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := tx.Query("SELECT id, archived FROM outlet WHERE id = ANY($1) ORDER BY id FOR SHARE", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var archived bool
+		err := rows.Scan(&id, &archived)
+		if err != nil {
+			return err
+		}
+		if archived {
+			return apperrors.Conflict(fmt.Sprintf("Outlet id %d sudah diarsipkan", id))
+		}
+	}
+	return rows.Err()
+}
+
+// transferItemsTx baris produk transfer (product_id -> qty kirim)
+func transferItemsTx(tx *sql.Tx, transferID int) ([]models.StockTransferItem, error) {
+	rows, err := tx.Query("SELECT id, product_id, quantity FROM stock_transfer_items WHERE transfer_id = $1 ORDER BY id", transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.StockTransferItem, 0)
+	for rows.Next() {
+		var item models.StockTransferItem
+		err := rows.Scan(&item.ID, &item.ProductID, &item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Ship kirim transfer, stok outlet asal langsung berkurang
+func (repo *TransferRepository) Ship(id int) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	transfer, err := lockTransferTx(tx, id, models.TransferDraft)
+	if err != nil {
+		return nil, err
+	}
+	err = lockActiveOutletsTx(tx, transfer.FromOutletID, transfer.ToOutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := transferItemsTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, apperrors.Validation(apperrors.CodeRequired, "items", "Transfer belum punya produk")
+	}
+
+	for _, item := range items {
+		available, err := lockOutletStock(tx, transfer.FromOutletID, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if available < item.Quantity {
+			return nil, apperrors.InsufficientStock("quantity", fmt.Sprintf("stok product id %d di outlet asal tidak cukup (sisa %d)", item.ProductID, available))
+		}
+		err = adjustOutletStock(tx, transfer.FromOutletID, item.ProductID, -item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfer SET status = $1, shipped_at = NOW() WHERE id = $2", models.TransferShipped, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Receive terima transfer, stok outlet tujuan bertambah sebanyak qty yang diterima.
+// Kekurangan dicatat per baris, lalu dikembalikan ke outlet asal (ReturnShortage) atau di-write-off
+// atas nama outlet asal supaya stok yang dikirim selalu tercatat ke mana perginya
+func (repo *TransferRepository) Receive(id int, req models.ReceiveTransferRequest) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	transfer, err := lockTransferTx(tx, id, models.TransferShipped)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := transferItemsTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// outlet dikunci supaya tidak diarsipkan bersamaan dengan stok yang masuk atau dikembalikan
+	outletIDs := []int{transfer.ToOutletID}
+	if req.ReturnShortage {
+		outletIDs = append(outletIDs, transfer.FromOutletID)
+	}
+	err = lockActiveOutletsTx(tx, outletIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	shipped := make(map[int]int, len(items))
+	for _, item := range items {
+		shipped[item.ProductID] += item.Quantity
+	}
+	received := make(map[int]int, len(req.Items))
+	for _, r := range req.Items {
+		quantity, ok := shipped[r.ProductID]
+		if !ok {
+			return nil, apperrors.Validation(apperrors.CodeInvalidReference, "product_id", fmt.Sprintf("product id %d tidak ada di transfer ini", r.ProductID))
+		}
+		// barang yang diterima tidak boleh lebih dari yang dikirim, kelebihan akan jadi stok dari ketiadaan
+		if r.ReceivedQuantity > quantity {
+			return nil, apperrors.Validation(apperrors.CodeInvalid, "received_quantity",
+				fmt.Sprintf("Qty diterima product id %d melebihi qty dikirim (%d)", r.ProductID, quantity))
+		}
+		received[r.ProductID] = r.ReceivedQuantity
+	}
+
+	shortageAction := ""
+	for _, item := range items {
+		quantity, ok := received[item.ProductID]
+		if !ok {
+			quantity = item.Quantity
+		}
+
+		_, err = tx.Exec("UPDATE stock_transfer_items SET received_quantity = $1 WHERE id = $2", quantity, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		err = adjustOutletStock(tx, transfer.ToOutletID, item.ProductID, quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		short := item.Quantity - quantity
+		if short == 0 {
+			continue
+		}
+		if req.ReturnShortage {
+			shortageAction = models.ShortageReturned
+			err = adjustOutletStock(tx, transfer.FromOutletID, item.ProductID, short)
+		} else {
+			shortageAction = models.ShortageWrittenOff
+			_, err = tx.Exec(`
+				INSERT INTO stock_write_off (outlet_id, product_id, quantity, reason, transfer_id, note)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, transfer.FromOutletID, item.ProductID, short, models.WriteOffTransferShortage, id, req.Note)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfer SET status = $1, received_at = NOW(), receive_note = $2, shortage_action = $3 WHERE id = $4",
+		models.TransferReceived, req.Note, shortageAction, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Cancel batalkan transfer yang belum dikirim
+func (repo *TransferRepository) Cancel(id int) (*models.StockTransfer, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = lockTransferTx(tx, id, models.TransferDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfer SET status = $1 WHERE id = $2", models.TransferCancelled, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
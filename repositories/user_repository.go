@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+const userColumns = `id, username, name, role, outlet_id, created_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }, u *models.User) error {
+	return row.Scan(&u.ID, &u.Username, &u.Name, &u.Role, &u.OutletID, &u.CreatedAt)
+}
+
+func (repo *UserRepository) GetAll() ([]models.User, error) {
+	rows, err := repo.db.Query("SELECT " + userColumns + " FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var u models.User
+		err := scanUser(rows, &u)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// GetByTokenHash user pemilik token, yang disimpan hanya hash sha256 token
+func (repo *UserRepository) GetByTokenHash(tokenHash string) (*models.User, error) {
+	var u models.User
+	err := scanUser(repo.db.QueryRow("SELECT "+userColumns+" FROM users WHERE token_hash = $1", tokenHash), &u)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.Unauthorized("Token tidak valid")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Exists true kalau sudah ada minimal satu user
+func (repo *UserRepository) Exists() (bool, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM users)").Scan(&exists)
+	return exists, err
+}
+
+func (repo *UserRepository) Create(user *models.User, tokenHash string) error {
+	query := "INSERT INTO users (username, name, role, outlet_id, token_hash) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at"
+	return repo.db.QueryRow(query, user.Username, user.Name, user.Role, user.OutletID, tokenHash).Scan(&user.ID, &user.CreatedAt)
+}
+
+func (repo *UserRepository) Delete(id int) error {
+	result, err := repo.db.Exec("DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return apperrors.NotFound("User tidak ditemukan")
+	}
+	return nil
+}
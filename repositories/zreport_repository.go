@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ZReportRepository struct {
+	db *sql.DB
+}
+
+func NewZReportRepository(db *sql.DB) *ZReportRepository {
+	return &ZReportRepository{db: db}
+}
+
+const zReportSelect = `
+	SELECT z.id, z.sequence, z.outlet_id, o.name, to_char(z.business_date, 'YYYY-MM-DD'), z.period_start, z.period_end,
+	       z.transaction_count, z.items_sold, z.gross_sales, z.points_redeemed, z.net_sales, z.payments,
+	       z.first_transaction_id, z.last_transaction_id, z.note, z.closed_by, z.closed_at
+	FROM z_reports z
+	JOIN outlet o ON z.outlet_id = o.id`
+
+func scanZReport(row interface{ Scan(...interface{}) error }, z *models.ZReport) error {
+	var payments []byte
+	err := row.Scan(&z.ID, &z.Sequence, &z.OutletID, &z.OutletName, &z.BusinessDate, &z.PeriodStart, &z.PeriodEnd,
+		&z.TransactionCount, &z.ItemsSold, &z.GrossSales, &z.PointsRedeemed, &z.NetSales, &payments,
+		&z.FirstTransactionID, &z.LastTransactionID, &z.Note, &z.ClosedBy, &z.ClosedAt)
+	if err != nil {
+		return err
+	}
+	z.PointsDiscount = z.GrossSales - z.NetSales
+	if z.TransactionCount > 0 {
+		z.AverageBasket = z.GrossSales / z.TransactionCount
+	}
+	return json.Unmarshal(payments, &z.Payments)
+}
+
+// GetAll daftar Z report terbaru di atas, outletID 0 semua outlet, tanggal bisnis dalam startDate s/d endDate
+func (repo *ZReportRepository) GetAll(outletID int, startDate, endDate string) ([]models.ZReport, error) {
+	query := zReportSelect
+	conditions := []string{"z.business_date BETWEEN $1 AND $2"}
+	args := []interface{}{startDate, endDate}
+	if outletID > 0 {
+		args = append(args, outletID)
+		conditions = append(conditions, fmt.Sprintf("z.outlet_id = $%d", len(args)))
+	}
+	query += " WHERE " + strings.Join(conditions, " AND ") + " ORDER BY z.business_date DESC, z.outlet_id"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]models.ZReport, 0)
+	for rows.Next() {
+		var z models.ZReport
+		err := scanZReport(rows, &z)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, z)
+	}
+	return reports, rows.Err()
+}
+
+func (repo *ZReportRepository) GetByID(id int) (*models.ZReport, error) {
+	var z models.ZReport
+	err := scanZReport(repo.db.QueryRow(zReportSelect+" WHERE z.id = $1", id), &z)
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("Z report tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &z, nil
+}
+
+// Close tutup hari bisnis businessDate ([start, end) dalam waktu toko) di outlet: hitung agregat,
+// simpan sebagai Z report dengan nomor urut berikutnya. Outlet dikunci supaya tidak ada checkout
+// yang masuk di tengah perhitungan
+func (repo *ZReportRepository) Close(req models.ZReportRequest, start, end time.Time, closedBy *int) (*models.ZReport, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	outletID, err := resolveOutletTx(tx, req.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("SELECT id FROM outlet WHERE id = $1 FOR UPDATE", outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM z_reports WHERE outlet_id = $1 AND business_date = $2)",
+		outletID, req.BusinessDate).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, apperrors.Conflict(fmt.Sprintf("Hari bisnis %s sudah ditutup", req.BusinessDate))
+	}
+
+	z := models.ZReport{OutletID: outletID, BusinessDate: req.BusinessDate, PeriodStart: start, PeriodEnd: end, Note: req.Note, ClosedBy: closedBy}
+	z.GrossSales, z.TransactionCount, err = salesTotals(tx, start, end, outletID)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(points_redeemed), 0), COALESCE(SUM(COALESCE(amount_due, total_amount)), 0), MIN(id), MAX(id)
+		FROM transactions
+		WHERE created_at >= $1 AND created_at < $2 AND outlet_id = $3
+	`, start, end, outletID).Scan(&z.PointsRedeemed, &z.NetSales, &z.FirstTransactionID, &z.LastTransactionID)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(td.quantity), 0)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.outlet_id = $3
+	`, start, end, outletID).Scan(&z.ItemsSold)
+	if err != nil {
+		return nil, err
+	}
+	z.Payments, err = paymentsByMethod(tx, start, end, outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := json.Marshal(z.Payments)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(`
+		INSERT INTO z_reports (outlet_id, sequence, business_date, period_start, period_end, transaction_count, items_sold,
+		                       gross_sales, points_redeemed, net_sales, payments, first_transaction_id, last_transaction_id, note, closed_by)
+		VALUES ($1, (SELECT COALESCE(MAX(sequence), 0) + 1 FROM z_reports WHERE outlet_id = $1), $2, $3, $4, $5, $6,
+		        $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`, outletID, req.BusinessDate, start, end, z.TransactionCount, z.ItemsSold,
+		z.GrossSales, z.PointsRedeemed, z.NetSales, string(payments), z.FirstTransactionID, z.LastTransactionID, z.Note, z.ClosedBy).Scan(&z.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// baca ulang supaya nomor urut, nama outlet dan closed_at sama persis dengan yang tersimpan
+	err = scanZReport(tx.QueryRow(zReportSelect+" WHERE z.id = $1", z.ID), &z)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &z, nil
+}
+
+// paymentsByMethod total amount_due per metode pembayaran, semua metode tetap muncul walau 0
+func paymentsByMethod(q queryer, start, end time.Time, outletID int) ([]models.ZReportPayment, error) {
+	rows, err := q.Query(`
+		SELECT payment_method, COUNT(*), COALESCE(SUM(COALESCE(amount_due, total_amount)), 0)
+		FROM transactions
+		WHERE created_at >= $1 AND created_at < $2 AND outlet_id = $3
+		GROUP BY payment_method
+	`, start, end, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[string]models.ZReportPayment)
+	for rows.Next() {
+		var p models.ZReportPayment
+		err := rows.Scan(&p.Method, &p.TransactionCount, &p.Amount)
+		if err != nil {
+			return nil, err
+		}
+		totals[p.Method] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	payments := make([]models.ZReportPayment, 0, len(models.PaymentMethods))
+	for _, method := range models.PaymentMethods {
+		p := totals[method]
+		p.Method = method
+		payments = append(payments, p)
+		delete(totals, method)
+	}
+	// metode lama di luar daftar tetap dilaporkan supaya total cocok
+	others := make([]string, 0, len(totals))
+	for method := range totals {
+		others = append(others, method)
+	}
+	sort.Strings(others)
+	for _, method := range others {
+		payments = append(payments, totals[method])
+	}
+	return payments, nil
+}
+
+// checkBusinessDayOpenTx tolak checkout kalau hari bisnis outlet saat ini sudah ditutup lewat Z report.
+// FOR SHARE di outlet supaya tidak balapan dengan Close yang mengunci outlet FOR UPDATE
+func checkBusinessDayOpenTx(tx *sql.Tx, outletID int) error {
+	_, err := tx.Exec("SELECT id FROM outlet WHERE id = $1 FOR SHARE", outletID)
+	if err != nil {
+		return err
+	}
+
+	var businessDate string
+	err = tx.QueryRow(`
+		SELECT to_char(business_date, 'YYYY-MM-DD') FROM z_reports
+		WHERE outlet_id = $1 AND period_start <= NOW() AND period_end > NOW()
+	`, outletID).Scan(&businessDate)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return apperrors.Conflict(fmt.Sprintf("Hari bisnis %s sudah ditutup, transaksi baru tidak bisa dibuat", businessDate))
+}
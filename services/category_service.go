@@ -1,24 +1,104 @@
 package services
 
 import (
+	"errors"
+	"kasir-api/apperrors"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"time"
 )
 
 type CategoryService struct {
-	repo *repositories.CategoryRepository
+	repo        *repositories.CategoryRepository
+	productRepo *repositories.ProductRepository
+	loc         *time.Location // zona waktu toko untuk batas tanggal statistik
 }
 
-func NewCategoryService(repo *repositories.CategoryRepository) *CategoryService {
-	return &CategoryService{repo: repo}
+func NewCategoryService(repo *repositories.CategoryRepository, productRepo *repositories.ProductRepository, loc *time.Location) *CategoryService {
+	return &CategoryService{repo: repo, productRepo: productRepo, loc: loc}
 }
 
-func (s *CategoryService) GetAll() ([]models.Category, error) {
-	return s.repo.GetAll()
+func (s *CategoryService) GetAll(includeArchived bool) ([]models.Category, error) {
+	return s.repo.GetAll(includeArchived)
 }
 
 func (s *CategoryService) Create(data *models.Category) error {
-	return s.repo.Create(data)
+	err := validateCategory(data)
+	if err != nil {
+		return err
+	}
+	err = s.validateParent(data)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Create(data))
+}
+
+// GetTree susun kategori jadi pohon berdasarkan parent_id
+func (s *CategoryService) GetTree(includeArchived bool) ([]models.Category, error) {
+	categories, err := s.repo.GetAll(includeArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		exists[c.ID] = true
+	}
+
+	// parent yang tidak ikut tampil (misal di-archive) dianggap root
+	childrenOf := make(map[int][]models.Category)
+	for _, c := range categories {
+		parentID := 0
+		if c.ParentID != nil && exists[*c.ParentID] {
+			parentID = *c.ParentID
+		}
+		childrenOf[parentID] = append(childrenOf[parentID], c)
+	}
+
+	var build func(parentID int) []models.Category
+	build = func(parentID int) []models.Category {
+		nodes := make([]models.Category, 0, len(childrenOf[parentID]))
+		for _, c := range childrenOf[parentID] {
+			c.Children = build(c.ID)
+			nodes = append(nodes, c)
+		}
+		return nodes
+	}
+
+	return build(0), nil
+}
+
+// validateParent parent harus ada dan tidak boleh membuat siklus
+func (s *CategoryService) validateParent(category *models.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+	if *category.ParentID == category.ID {
+		return apperrors.Validation(apperrors.CodeInvalid, "parent_id", "Kategori tidak boleh menjadi parent dirinya sendiri")
+	}
+
+	parent, err := s.repo.GetByID(*category.ParentID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return apperrors.Validation(apperrors.CodeInvalidReference, "parent_id", "Parent kategori tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+	if parent.Archived {
+		return apperrors.Validation(apperrors.CodeInvalidReference, "parent_id", "Parent kategori sudah di-archive")
+	}
+
+	if category.ID > 0 {
+		isDescendant, err := s.repo.IsDescendant(category.ID, parent.ID)
+		if err != nil {
+			return err
+		}
+		if isDescendant {
+			return apperrors.Validation(apperrors.CodeInvalid, "parent_id", "Parent kategori tidak boleh sub kategori dari kategori ini")
+		}
+	}
+	return nil
 }
 
 // GetByID
@@ -26,12 +106,53 @@ func (s *CategoryService) GetByID(id int) (*models.Category, error) {
 	return s.repo.GetByID(id)
 }
 
+// GetDetail kategori plus produk dan/atau statistik sesuai permintaan, statistik outletID > 0 hanya untuk outlet tersebut
+func (s *CategoryService) GetDetail(id int, withProducts, withStats bool, q models.PeriodQuery, outletID int) (*models.CategoryDetail, error) {
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := models.CategoryDetail{Category: *category}
+	if withProducts {
+		detail.Products, err = s.productRepo.GetAll(models.ProductFilter{CategoryID: id})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if withStats {
+		period, err := parsePeriod(s.loc, q, MaxReportRangeDays)
+		if err != nil {
+			return nil, err
+		}
+		detail.Stats, err = s.repo.GetStats(id, period.Start, period.End, outletID)
+		if err != nil {
+			return nil, err
+		}
+		detail.Stats.StartDate, detail.Stats.EndDate = period.StartDate, period.EndDate
+	}
+	return &detail, nil
+}
+
 // Update (By ID tentunya)
 func (s *CategoryService) Update(category *models.Category) error {
-	return s.repo.Update(category)
+	err := validateCategory(category)
+	if err != nil {
+		return err
+	}
+	err = s.validateParent(category)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Update(category))
 }
 
 // Delete (By ID jugaaa)
-func (s *CategoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *CategoryService) Delete(id int, moveTo int, uncategorize bool) error {
+	return translateDBError(s.repo.Delete(id, moveTo, uncategorize))
+}
+
+// Restore (un-archive By ID)
+func (s *CategoryService) Restore(id int) error {
+	return s.repo.Restore(id)
 }
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+type CustomerService struct {
+	repo            *repositories.CustomerRepository
+	transactionRepo *repositories.TransactionRepository
+}
+
+func NewCustomerService(repo *repositories.CustomerRepository, transactionRepo *repositories.TransactionRepository) *CustomerService {
+	return &CustomerService{repo: repo, transactionRepo: transactionRepo}
+}
+
+func (s *CustomerService) GetAll(search string) ([]models.Customer, error) {
+	return s.repo.GetAll(search)
+}
+
+func (s *CustomerService) GetByID(id int) (*models.Customer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *CustomerService) Create(customer *models.Customer) error {
+	err := validateCustomer(customer)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Create(customer))
+}
+
+func (s *CustomerService) Update(customer *models.Customer) error {
+	err := validateCustomer(customer)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Update(customer))
+}
+
+func (s *CustomerService) Delete(id int) error {
+	return translateDBError(s.repo.Delete(id))
+}
+
+// GetTransactions histori belanja customer
+func (s *CustomerService) GetTransactions(id int) ([]models.Transaction, error) {
+	_, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.transactionRepo.GetByCustomer(id)
+}
+
+// GetLedger histori poin customer
+func (s *CustomerService) GetLedger(id int) ([]models.LoyaltyEntry, error) {
+	_, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetLedger(id)
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type OrderService struct {
+	repo         *repositories.OrderRepository
+	reserveStock bool
+	loyalty      models.LoyaltyConfig
+}
+
+// NewOrderService reserveStock true berarti stok item order langsung ditahan sampai checkout / batal
+func NewOrderService(repo *repositories.OrderRepository, reserveStock bool, loyalty models.LoyaltyConfig) *OrderService {
+	return &OrderService{repo: repo, reserveStock: reserveStock, loyalty: loyalty}
+}
+
+func validateQuantity(quantity int) error {
+	if quantity <= 0 {
+		return apperrors.Validation(apperrors.CodeInvalid, "quantity", "Quantity harus lebih dari 0")
+	}
+	return nil
+}
+
+func (s *OrderService) GetAll(status string, tableID, outletID int) ([]models.Order, error) {
+	return s.repo.GetAll(status, tableID, outletID)
+}
+
+func (s *OrderService) GetByID(id int) (*models.Order, error) {
+	return s.repo.GetByID(id)
+}
+
+// Create order draft, boleh tanpa item (open tab), table_id untuk order dine-in
+func (s *OrderService) Create(req models.OrderRequest) (*models.Order, error) {
+	for _, item := range req.Items {
+		err := validateQuantity(item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+	}
+	req.PriceList = strings.ToLower(strings.TrimSpace(req.PriceList))
+	req.Note = strings.TrimSpace(req.Note)
+	if req.TableID < 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "table_id", "Table ID tidak valid")
+	}
+
+	order, err := s.repo.Create(req, s.reserveStock)
+	if err != nil {
+		return nil, translateDBError(err)
+	}
+	return order, nil
+}
+
+func (s *OrderService) AddItem(orderID int, item models.CheckoutItem) (*models.Order, error) {
+	err := validateQuantity(item.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.AddItem(orderID, item.ProductID, item.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(orderID)
+}
+
+// UpdateItem quantity 0 berarti item dihapus
+func (s *OrderService) UpdateItem(orderID, itemID, quantity int) (*models.Order, error) {
+	var err error
+	switch {
+	case quantity < 0:
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "quantity", "Quantity tidak boleh negatif")
+	case quantity == 0:
+		err = s.repo.RemoveItem(orderID, itemID)
+	default:
+		err = s.repo.UpdateItem(orderID, itemID, quantity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(orderID)
+}
+
+func (s *OrderService) RemoveItem(orderID, itemID int) (*models.Order, error) {
+	err := s.repo.RemoveItem(orderID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(orderID)
+}
+
+// Park simpan order sementara, item tidak bisa diubah sampai di-resume
+func (s *OrderService) Park(id int) (*models.Order, error) {
+	err := s.repo.ChangeStatus(id, models.OrderDraft, models.OrderParked)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *OrderService) Resume(id int) (*models.Order, error) {
+	err := s.repo.ChangeStatus(id, models.OrderParked, models.OrderDraft)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *OrderService) Cancel(id int) (*models.Order, error) {
+	err := s.repo.Cancel(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// SendToKitchen buat tiket dapur ronde berikutnya dari item yang belum dikirim
+func (s *OrderService) SendToKitchen(id int) (*models.KitchenTicket, error) {
+	return s.repo.SendToKitchen(id)
+}
+
+func (s *OrderService) GetTickets(id int) ([]models.KitchenTicket, error) {
+	_, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetTickets(id, 0)
+}
+
+func (s *OrderService) Checkout(id int, req models.OrderCheckoutRequest) (*models.Transaction, error) {
+	if req.RedeemPoints < 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "redeem_points", "Poin tidak boleh negatif")
+	}
+
+	order, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.RedeemPoints > 0 && order.CustomerID == nil {
+		return nil, apperrors.Validation(apperrors.CodeRequired, "customer_id", "Customer wajib diisi untuk tukar poin")
+	}
+	req.PaymentMethod, err = validatePaymentMethod(req.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := s.repo.Checkout(id, req, s.loyalty)
+	if err != nil {
+		return nil, translateDBError(err)
+	}
+	return transaction, nil
+}
+
+// MaxSplitWays batas jumlah pembayar split rata, satu baris pembayaran per pembayar
+const MaxSplitWays = 50
+
+// Split bayar order oleh beberapa pembayar, per item atau dibagi rata
+func (s *OrderService) Split(id int, req models.SplitRequest) (*models.SplitResult, error) {
+	req.Mode = strings.ToLower(strings.TrimSpace(req.Mode))
+
+	order, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Mode {
+	case models.SplitByItems:
+		if len(req.Parts) < 2 {
+			return nil, apperrors.Validation(apperrors.CodeInvalid, "parts", "Split per item minimal 2 bagian")
+		}
+		for i, part := range req.Parts {
+			req.Parts[i].PaymentMethod, err = validatePaymentMethod(part.PaymentMethod)
+			if err != nil {
+				return nil, err
+			}
+			if len(part.Items) == 0 {
+				return nil, apperrors.Validation(apperrors.CodeRequired, "items", "Setiap bagian wajib punya item")
+			}
+			for _, item := range part.Items {
+				err := validateQuantity(item.Quantity)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if part.RedeemPoints < 0 {
+				return nil, apperrors.Validation(apperrors.CodeInvalid, "redeem_points", "Poin tidak boleh negatif")
+			}
+			if part.RedeemPoints > 0 && part.CustomerID == 0 && order.CustomerID == nil {
+				return nil, apperrors.Validation(apperrors.CodeRequired, "customer_id", "Customer wajib diisi untuk tukar poin")
+			}
+		}
+		result, err := s.repo.SplitByItems(id, req.Parts, s.loyalty)
+		if err != nil {
+			return nil, translateDBError(err)
+		}
+		return result, nil
+
+	case models.SplitEven:
+		if req.Ways < 2 || req.Ways > MaxSplitWays {
+			return nil, apperrors.Validation(apperrors.CodeInvalid, "ways", fmt.Sprintf("Split rata harus antara 2 dan %d pembayar", MaxSplitWays))
+		}
+		if req.RedeemPoints < 0 {
+			return nil, apperrors.Validation(apperrors.CodeInvalid, "redeem_points", "Poin tidak boleh negatif")
+		}
+		if req.RedeemPoints > 0 && order.CustomerID == nil {
+			return nil, apperrors.Validation(apperrors.CodeRequired, "customer_id", "Customer wajib diisi untuk tukar poin")
+		}
+		method, err := validatePaymentMethod(req.PaymentMethod)
+		if err != nil {
+			return nil, err
+		}
+		result, err := s.repo.SplitEven(id, req.Ways, models.OrderCheckoutRequest{RedeemPoints: req.RedeemPoints, PaymentMethod: method}, s.loyalty)
+		if err != nil {
+			return nil, translateDBError(err)
+		}
+		return result, nil
+	}
+	return nil, apperrors.Validation(apperrors.CodeInvalid, "mode", "Mode split harus items atau even")
+}
+
+// GetReceipts struk setiap pembayaran order
+func (s *OrderService) GetReceipts(id int) ([]models.Receipt, error) {
+	_, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetReceipts(id)
+}
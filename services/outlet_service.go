@@ -0,0 +1,90 @@
+package services
+
+import (
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type OutletService struct {
+	repo *repositories.OutletRepository
+}
+
+func NewOutletService(repo *repositories.OutletRepository) *OutletService {
+	return &OutletService{repo: repo}
+}
+
+func validateOutlet(o *models.Outlet) error {
+	o.Code = strings.ToLower(strings.TrimSpace(o.Code))
+	o.Name = strings.TrimSpace(o.Name)
+	o.Address = strings.TrimSpace(o.Address)
+	if o.Code == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "code", "Kode outlet wajib diisi")
+	}
+	if o.Name == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "name", "Nama outlet wajib diisi")
+	}
+	return nil
+}
+
+func (s *OutletService) GetAll(includeArchived bool) ([]models.Outlet, error) {
+	return s.repo.GetAll(includeArchived)
+}
+
+func (s *OutletService) GetByID(id int) (*models.Outlet, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *OutletService) Create(outlet *models.Outlet) error {
+	err := validateOutlet(outlet)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Create(outlet))
+}
+
+func (s *OutletService) Update(outlet *models.Outlet) error {
+	err := validateOutlet(outlet)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.repo.GetByID(outlet.ID)
+	if err != nil {
+		return err
+	}
+	if current.Code == repositories.DefaultOutletCode && outlet.Code != current.Code {
+		return apperrors.Validation(apperrors.CodeInvalid, "code", "Kode outlet default tidak bisa diubah")
+	}
+	return translateDBError(s.repo.Update(outlet))
+}
+
+func (s *OutletService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *OutletService) Restore(id int) error {
+	return s.repo.Restore(id)
+}
+
+func (s *OutletService) GetStock(id int) ([]models.OutletStock, error) {
+	_, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetStock(id)
+}
+
+// SetStock set stok absolut per produk (hasil stock opname / barang masuk)
+func (s *OutletService) SetStock(id int, updates []models.OutletStockUpdate) error {
+	if len(updates) == 0 {
+		return apperrors.Validation(apperrors.CodeRequired, "items", "Data stok wajib diisi")
+	}
+	for _, u := range updates {
+		if u.Stock < 0 {
+			return apperrors.Validation(apperrors.CodeInvalid, "stock", "Stok tidak boleh negatif")
+		}
+	}
+	return s.repo.SetStock(id, updates)
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type PriceListService struct {
+	repo *repositories.PriceListRepository
+}
+
+func NewPriceListService(repo *repositories.PriceListRepository) *PriceListService {
+	return &PriceListService{repo: repo}
+}
+
+func (s *PriceListService) GetAll() ([]models.PriceList, error) {
+	return s.repo.GetAll()
+}
+
+func (s *PriceListService) Create(priceList *models.PriceList) error {
+	priceList.Code = strings.ToLower(strings.TrimSpace(priceList.Code))
+	priceList.Name = strings.TrimSpace(priceList.Name)
+	if priceList.Code == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "code", "Kode daftar harga wajib diisi")
+	}
+	if priceList.Name == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "name", "Nama daftar harga wajib diisi")
+	}
+	return translateDBError(s.repo.Create(priceList))
+}
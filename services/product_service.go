@@ -1,24 +1,35 @@
 package services
 
 import (
+	"errors"
+	"kasir-api/apperrors"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"log"
+	"strings"
+	"time"
 )
 
 type ProductService struct {
-	repo *repositories.ProductRepository
+	repo          *repositories.ProductRepository
+	categoryRepo  *repositories.CategoryRepository
+	priceListRepo *repositories.PriceListRepository
 }
 
-func NewProductService(repo *repositories.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository, priceListRepo *repositories.PriceListRepository) *ProductService {
+	return &ProductService{repo: repo, categoryRepo: categoryRepo, priceListRepo: priceListRepo}
 }
 
-func (s *ProductService) GetAll(name string) ([]models.Product, error) {
-	return s.repo.GetAll(name)
+func (s *ProductService) GetAll(filter models.ProductFilter) ([]models.Product, error) {
+	return s.repo.GetAll(filter)
 }
 
 func (s *ProductService) Create(data *models.Product) error {
-	return s.repo.Create(data)
+	err := s.validate(data)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Create(data))
 }
 
 // Product By ID
@@ -28,10 +39,167 @@ func (s *ProductService) GetByID(id int) (*models.Product, error) {
 
 // Update (By ID tentunya)
 func (s *ProductService) Update(product *models.Product) error {
-	return s.repo.Update(product)
+	return s.update(product, true)
+}
+
+// update validasi lalu simpan, setStock false kalau stok tidak ikut diubah
+func (s *ProductService) update(product *models.Product, setStock bool) error {
+	err := s.validate(product)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Update(product, setStock))
+}
+
+// Patch hanya ubah field yang dikirim, expectedVersion 0 berarti pakai versi saat dibaca
+func (s *ProductService) Patch(id int, patch models.ProductPatch, expectedVersion int) (*models.Product, error) {
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion == 0 && patch.Version != nil {
+		expectedVersion = *patch.Version
+	}
+	if expectedVersion > 0 && expectedVersion != product.Version {
+		return nil, apperrors.PreconditionFailed("Produk sudah diubah oleh orang lain, ambil data terbaru dulu")
+	}
+
+	if patch.Name != nil {
+		product.Name = *patch.Name
+	}
+	if patch.Price != nil {
+		product.Price = *patch.Price
+	}
+	if patch.Stock != nil {
+		product.Stock = *patch.Stock
+	}
+	if patch.Cost != nil {
+		product.Cost = patch.Cost
+	}
+	if patch.CategoryID != nil {
+		product.CategoryID = *patch.CategoryID
+	}
+
+	// stok yang dibaca di atas bisa sudah basi karena checkout, jadi hanya disimpan kalau memang dikirim
+	err = s.update(product, patch.Stock != nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
 }
 
 // Delete (juga By ID)
 func (s *ProductService) Delete(id int) error {
-	return s.repo.Delete(id)
+	return translateDBError(s.repo.Delete(id))
+}
+
+// validate field produk, category_id (kalau diisi) harus kategori yang aktif
+func (s *ProductService) validate(product *models.Product) error {
+	err := validateProduct(product)
+	if err != nil {
+		return err
+	}
+
+	if product.CategoryID > 0 {
+		category, err := s.categoryRepo.GetByID(product.CategoryID)
+		if errors.Is(err, apperrors.ErrNotFound) || (err == nil && category.Archived) {
+			return apperrors.Validation(apperrors.CodeInvalidReference, "category_id", "Kategori tidak ditemukan")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore (un-archive By ID)
+func (s *ProductService) Restore(id int) error {
+	return s.repo.Restore(id)
+}
+
+func (s *ProductService) GetPriceHistory(productID int) ([]models.PriceHistory, error) {
+	_, err := s.repo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPriceHistory(productID)
+}
+
+func (s *ProductService) GetPriceSchedules(productID int) ([]models.PriceSchedule, error) {
+	_, err := s.repo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPriceSchedules(productID)
+}
+
+// SchedulePriceChange jadwalkan harga baru yang otomatis berlaku di effective_at
+func (s *ProductService) SchedulePriceChange(schedule *models.PriceSchedule) error {
+	product, err := s.repo.GetByID(schedule.ProductID)
+	if err != nil {
+		return err
+	}
+	if product.Archived {
+		return apperrors.Validation(apperrors.CodeInvalid, "product_id", "Produk sudah di-archive")
+	}
+	if schedule.NewPrice < 0 {
+		return apperrors.Validation(apperrors.CodeInvalid, "new_price", "Harga tidak boleh negatif")
+	}
+	if !schedule.EffectiveAt.After(time.Now()) {
+		return apperrors.Validation(apperrors.CodeInvalid, "effective_at", "Waktu berlaku harus di masa depan")
+	}
+	return s.repo.CreatePriceSchedule(schedule)
+}
+
+func (s *ProductService) CancelPriceSchedule(productID, scheduleID int) error {
+	return s.repo.CancelPriceSchedule(productID, scheduleID)
+}
+
+func (s *ProductService) GetPrices(productID int) ([]models.ProductPriceTier, error) {
+	_, err := s.repo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.priceListRepo.GetProductPrices(productID)
+}
+
+// SetPrices ganti semua tier harga produk (harga grosir, member, dan potongan per qty)
+func (s *ProductService) SetPrices(productID int, tiers []models.ProductPriceTier) error {
+	_, err := s.repo.GetByID(productID)
+	if err != nil {
+		return err
+	}
+
+	for i := range tiers {
+		tiers[i].PriceList = strings.ToLower(strings.TrimSpace(tiers[i].PriceList))
+		if tiers[i].PriceList == "" {
+			return apperrors.Validation(apperrors.CodeRequired, "price_list", "Daftar harga wajib diisi")
+		}
+		if tiers[i].MinQty < 1 {
+			return apperrors.Validation(apperrors.CodeInvalid, "min_qty", "Minimal qty paling sedikit 1")
+		}
+		if tiers[i].Price < 0 {
+			return apperrors.Validation(apperrors.CodeInvalid, "price", "Harga tidak boleh negatif")
+		}
+	}
+
+	return translateDBError(s.priceListRepo.ReplaceProductPrices(productID, tiers))
+}
+
+// RunPriceScheduler cek jadwal harga setiap interval, dijalankan sebagai goroutine
+func (s *ProductService) RunPriceScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		applied, err := s.repo.ApplyDuePriceSchedules()
+		if err != nil {
+			log.Println("Gagal menerapkan jadwal harga:", err)
+			continue
+		}
+		if applied > 0 {
+			log.Printf("%d jadwal harga diterapkan\n", applied)
+		}
+	}
 }
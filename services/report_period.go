@@ -0,0 +1,155 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"strings"
+	"time"
+)
+
+// MaxReportRangeDays rentang tanggal terpanjang untuk laporan biasa
+const MaxReportRangeDays = 366
+
+// Nama rentang relatif, dihitung dari hari ini di zona waktu toko. Minggu dimulai hari Senin
+const (
+	RangeToday      = "today"
+	RangeYesterday  = "yesterday"
+	RangeThisWeek   = "this_week"  // Senin minggu ini s/d hari ini
+	RangeLastWeek   = "last_week"  // Senin s/d Minggu minggu lalu
+	RangeThisMonth  = "this_month" // sama dengan mtd
+	RangeLastMonth  = "last_month"
+	RangeMTD        = "mtd" // tanggal 1 bulan ini s/d hari ini
+	RangeYTD        = "ytd" // 1 Januari s/d hari ini
+	RangeLast7Days  = "last_7_days"
+	RangeLast30Days = "last_30_days"
+)
+
+// reportPeriod rentang laporan [Start, End) dari tanggal StartDate s/d EndDate di zona waktu toko
+type reportPeriod struct {
+	StartDate string
+	EndDate   string
+	Start     time.Time
+	End       time.Time
+}
+
+// days jumlah hari kalender dalam periode
+func (p reportPeriod) days() int {
+	start, _ := time.Parse("2006-01-02", p.StartDate)
+	end, _ := time.Parse("2006-01-02", p.EndDate)
+	return int(end.Sub(start).Hours()/24) + 1
+}
+
+// namedRange tanggal awal & akhir rentang relatif terhadap today
+func namedRange(name string, today time.Time) (time.Time, time.Time, bool) {
+	// Weekday Minggu = 0, dijadikan 7 supaya minggu mulai Senin
+	weekday := int(today.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	monday := today.AddDate(0, 0, 1-weekday)
+	firstOfMonth := today.AddDate(0, 0, 1-today.Day())
+
+	switch name {
+	case RangeToday:
+		return today, today, true
+	case RangeYesterday:
+		yesterday := today.AddDate(0, 0, -1)
+		return yesterday, yesterday, true
+	case RangeThisWeek:
+		return monday, today, true
+	case RangeLastWeek:
+		return monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1), true
+	case RangeThisMonth, RangeMTD:
+		return firstOfMonth, today, true
+	case RangeLastMonth:
+		return firstOfMonth.AddDate(0, -1, 0), firstOfMonth.AddDate(0, 0, -1), true
+	case RangeYTD:
+		return today.AddDate(0, 0, 1-today.YearDay()), today, true
+	case RangeLast7Days:
+		return today.AddDate(0, 0, -6), today, true
+	case RangeLast30Days:
+		return today.AddDate(0, 0, -29), today, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// parsePeriod ubah PeriodQuery jadi batas waktu di zona waktu loc. Pakai range atau start_date+end_date,
+// tidak boleh keduanya; kalau semua kosong dipakai hari ini. maxDays batas panjang rentang
+func parsePeriod(loc *time.Location, q models.PeriodQuery, maxDays int) (reportPeriod, error) {
+	q.Range = strings.ToLower(strings.TrimSpace(q.Range))
+	q.StartDate = strings.TrimSpace(q.StartDate)
+	q.EndDate = strings.TrimSpace(q.EndDate)
+
+	var start, end time.Time
+	switch {
+	case q.Range != "":
+		if q.StartDate != "" || q.EndDate != "" {
+			return reportPeriod{}, apperrors.Validation(apperrors.CodeInvalid, "range", "Pakai range atau start_date/end_date, tidak boleh keduanya")
+		}
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		var ok bool
+		start, end, ok = namedRange(q.Range, today)
+		if !ok {
+			return reportPeriod{}, apperrors.Validation(apperrors.CodeInvalid, "range",
+				"range harus today, yesterday, this_week, last_week, this_month, last_month, mtd, ytd, last_7_days atau last_30_days")
+		}
+	case q.StartDate == "" && q.EndDate == "":
+		now := time.Now().In(loc)
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		end = start
+	case q.StartDate == "":
+		return reportPeriod{}, apperrors.Validation(apperrors.CodeRequired, "start_date", "start_date wajib diisi kalau end_date diisi")
+	case q.EndDate == "":
+		return reportPeriod{}, apperrors.Validation(apperrors.CodeRequired, "end_date", "end_date wajib diisi kalau start_date diisi")
+	default:
+		var err error
+		start, err = time.ParseInLocation("2006-01-02", q.StartDate, loc)
+		if err != nil {
+			return reportPeriod{}, apperrors.Validation(apperrors.CodeInvalid, "start_date", "start_date harus tanggal valid dengan format YYYY-MM-DD")
+		}
+		end, err = time.ParseInLocation("2006-01-02", q.EndDate, loc)
+		if err != nil {
+			return reportPeriod{}, apperrors.Validation(apperrors.CodeInvalid, "end_date", "end_date harus tanggal valid dengan format YYYY-MM-DD")
+		}
+		if end.Before(start) {
+			return reportPeriod{}, apperrors.Validation(apperrors.CodeInvalid, "end_date", "end_date tidak boleh sebelum start_date")
+		}
+	}
+
+	// AddDate bukan Add(24 jam) supaya tetap benar di zona waktu yang punya DST
+	period := reportPeriod{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Start:     start,
+		End:       end.AddDate(0, 0, 1),
+	}
+	if period.days() > maxDays {
+		return reportPeriod{}, apperrors.Validation(apperrors.CodeInvalid, "end_date", fmt.Sprintf("Rentang tanggal maksimal %d hari", maxDays))
+	}
+	return period, nil
+}
+
+// comparePeriod periode pembanding untuk p sesuai mode
+func comparePeriod(p reportPeriod, mode string) (reportPeriod, error) {
+	var start, end time.Time
+	switch mode {
+	case models.ComparePrevious:
+		start = p.Start.AddDate(0, 0, -p.days())
+		end = p.Start.AddDate(0, 0, -1)
+	case models.CompareLastYear:
+		// 29 Februari jadi 1 Maret tahun lalu, mengikuti normalisasi AddDate
+		start = p.Start.AddDate(-1, 0, 0)
+		// hari terakhir dulu baru mundur setahun, supaya end tidak jatuh sebelum start
+		end = p.End.AddDate(0, 0, -1).AddDate(-1, 0, 0)
+	default:
+		return reportPeriod{}, apperrors.Validation(apperrors.CodeInvalid, "compare", "compare harus previous atau last_year")
+	}
+	return reportPeriod{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Start:     start,
+		End:       end.AddDate(0, 0, 1),
+	}, nil
+}
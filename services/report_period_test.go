@@ -0,0 +1,204 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestNamedRange(t *testing.T) {
+	jakarta := mustLoad(t, "Asia/Jakarta")
+	day := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", s, jakarta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name       string
+		today      string
+		rangeName  string
+		start, end string
+	}{
+		{"today", "2026-10-14", RangeToday, "2026-10-14", "2026-10-14"},
+		{"yesterday lintas bulan", "2026-10-01", RangeYesterday, "2026-09-30", "2026-09-30"},
+		{"this_week hari Rabu", "2026-10-14", RangeThisWeek, "2026-10-12", "2026-10-14"},
+		{"this_week hari Senin", "2026-10-12", RangeThisWeek, "2026-10-12", "2026-10-12"},
+		{"this_week hari Minggu", "2026-10-18", RangeThisWeek, "2026-10-12", "2026-10-18"},
+		{"last_week", "2026-10-14", RangeLastWeek, "2026-10-05", "2026-10-11"},
+		{"last_week hari Minggu", "2026-10-18", RangeLastWeek, "2026-10-05", "2026-10-11"},
+		{"this_month", "2026-10-14", RangeThisMonth, "2026-10-01", "2026-10-14"},
+		{"mtd", "2026-10-01", RangeMTD, "2026-10-01", "2026-10-01"},
+		{"last_month dari 31 Maret", "2026-03-31", RangeLastMonth, "2026-02-01", "2026-02-28"},
+		{"last_month kabisat", "2024-03-15", RangeLastMonth, "2024-02-01", "2024-02-29"},
+		{"last_month dari Januari", "2026-01-10", RangeLastMonth, "2025-12-01", "2025-12-31"},
+		{"ytd", "2026-10-14", RangeYTD, "2026-01-01", "2026-10-14"},
+		{"ytd 1 Januari", "2026-01-01", RangeYTD, "2026-01-01", "2026-01-01"},
+		{"last_7_days", "2026-10-14", RangeLast7Days, "2026-10-08", "2026-10-14"},
+		{"last_30_days", "2026-03-01", RangeLast30Days, "2026-01-31", "2026-03-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := namedRange(tt.rangeName, day(tt.today))
+			if !ok {
+				t.Fatalf("namedRange(%q) tidak dikenal", tt.rangeName)
+			}
+			if got := start.Format("2006-01-02"); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := end.Format("2006-01-02"); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+		})
+	}
+
+	if _, _, ok := namedRange("kemarin", day("2026-10-14")); ok {
+		t.Error("namedRange(\"kemarin\") seharusnya tidak dikenal")
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	jakarta := mustLoad(t, "Asia/Jakarta")
+	// Berlin punya DST: 29 Maret 2026 hanya 23 jam
+	berlin := mustLoad(t, "Europe/Berlin")
+
+	tests := []struct {
+		name       string
+		loc        *time.Location
+		q          models.PeriodQuery
+		maxDays    int
+		start, end string // RFC3339 batas [Start, End)
+		days       int
+		errField   string
+	}{
+		{
+			name: "satu hari", loc: jakarta, maxDays: MaxReportRangeDays,
+			q:     models.PeriodQuery{StartDate: "2026-10-14", EndDate: "2026-10-14"},
+			start: "2026-10-14T00:00:00+07:00", end: "2026-10-15T00:00:00+07:00", days: 1,
+		},
+		{
+			name: "spasi dipangkas", loc: jakarta, maxDays: MaxReportRangeDays,
+			q:     models.PeriodQuery{StartDate: " 2026-10-01 ", EndDate: "2026-10-31 "},
+			start: "2026-10-01T00:00:00+07:00", end: "2026-11-01T00:00:00+07:00", days: 31,
+		},
+		{
+			name: "melewati DST", loc: berlin, maxDays: MaxReportRangeDays,
+			q:     models.PeriodQuery{StartDate: "2026-03-29", EndDate: "2026-03-29"},
+			start: "2026-03-29T00:00:00+01:00", end: "2026-03-30T00:00:00+02:00", days: 1,
+		},
+		{
+			name: "batas maksimal", loc: jakarta, maxDays: 7,
+			q:     models.PeriodQuery{StartDate: "2026-10-01", EndDate: "2026-10-07"},
+			start: "2026-10-01T00:00:00+07:00", end: "2026-10-08T00:00:00+07:00", days: 7,
+		},
+		{name: "melebihi batas", loc: jakarta, maxDays: 7, q: models.PeriodQuery{StartDate: "2026-10-01", EndDate: "2026-10-08"}, errField: "end_date"},
+		{name: "end sebelum start", loc: jakarta, maxDays: 30, q: models.PeriodQuery{StartDate: "2026-10-02", EndDate: "2026-10-01"}, errField: "end_date"},
+		{name: "tanggal tidak ada", loc: jakarta, maxDays: 30, q: models.PeriodQuery{StartDate: "2026-02-30", EndDate: "2026-03-01"}, errField: "start_date"},
+		{name: "format salah", loc: jakarta, maxDays: 30, q: models.PeriodQuery{StartDate: "2026-10-01", EndDate: "01/10/2026"}, errField: "end_date"},
+		{name: "hanya end_date", loc: jakarta, maxDays: 30, q: models.PeriodQuery{EndDate: "2026-10-01"}, errField: "start_date"},
+		{name: "hanya start_date", loc: jakarta, maxDays: 30, q: models.PeriodQuery{StartDate: "2026-10-01"}, errField: "end_date"},
+		{name: "range dan tanggal", loc: jakarta, maxDays: 30, q: models.PeriodQuery{Range: RangeToday, StartDate: "2026-10-01"}, errField: "range"},
+		{name: "range tidak dikenal", loc: jakarta, maxDays: 30, q: models.PeriodQuery{Range: "kemarin"}, errField: "range"},
+		{name: "ytd melebihi batas", loc: jakarta, maxDays: 0, q: models.PeriodQuery{Range: RangeYTD}, errField: "end_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsePeriod(tt.loc, tt.q, tt.maxDays)
+			if tt.errField != "" {
+				var appErr *apperrors.Error
+				if !errors.As(err, &appErr) || appErr.Kind != apperrors.ErrValidation || appErr.Field != tt.errField {
+					t.Fatalf("err = %v, want validasi field %s", err, tt.errField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Start.Format(time.RFC3339); got != tt.start {
+				t.Errorf("Start = %s, want %s", got, tt.start)
+			}
+			if got := p.End.Format(time.RFC3339); got != tt.end {
+				t.Errorf("End = %s, want %s", got, tt.end)
+			}
+			if got := p.days(); got != tt.days {
+				t.Errorf("days() = %d, want %d", got, tt.days)
+			}
+		})
+	}
+}
+
+func TestParsePeriodRelative(t *testing.T) {
+	jakarta := mustLoad(t, "Asia/Jakarta")
+	now := time.Now().In(jakarta)
+	today := now.Format("2006-01-02")
+
+	for _, q := range []models.PeriodQuery{{}, {Range: "TODAY"}, {Range: " today "}} {
+		p, err := parsePeriod(jakarta, q, MaxReportRangeDays)
+		if err != nil {
+			t.Fatalf("parsePeriod(%+v): %v", q, err)
+		}
+		if p.StartDate != today || p.EndDate != today {
+			t.Errorf("parsePeriod(%+v) = %s s/d %s, want hari ini %s", q, p.StartDate, p.EndDate, today)
+		}
+		if p.Start.After(now) || !p.End.After(now) {
+			t.Errorf("parsePeriod(%+v) = [%s, %s) tidak mencakup sekarang", q, p.Start, p.End)
+		}
+	}
+}
+
+func TestComparePeriod(t *testing.T) {
+	jakarta := mustLoad(t, "Asia/Jakarta")
+
+	tests := []struct {
+		name       string
+		start, end string
+		mode       string
+		wantStart  string
+		wantEnd    string
+	}{
+		{"previous satu hari", "2026-10-14", "2026-10-14", models.ComparePrevious, "2026-10-13", "2026-10-13"},
+		{"previous satu minggu", "2026-10-12", "2026-10-18", models.ComparePrevious, "2026-10-05", "2026-10-11"},
+		{"previous satu bulan", "2026-03-01", "2026-03-31", models.ComparePrevious, "2026-01-29", "2026-02-28"},
+		{"last_year", "2026-10-01", "2026-10-31", models.CompareLastYear, "2025-10-01", "2025-10-31"},
+		{"last_year dari 29 Februari", "2024-02-29", "2024-02-29", models.CompareLastYear, "2023-03-01", "2023-03-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsePeriod(jakarta, models.PeriodQuery{StartDate: tt.start, EndDate: tt.end}, MaxReportRangeDays)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := comparePeriod(p, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.StartDate != tt.wantStart || c.EndDate != tt.wantEnd {
+				t.Errorf("comparePeriod() = %s s/d %s, want %s s/d %s", c.StartDate, c.EndDate, tt.wantStart, tt.wantEnd)
+			}
+			if !c.End.Equal(c.Start.AddDate(0, 0, c.days())) {
+				t.Errorf("End %s bukan tengah malam setelah EndDate", c.End)
+			}
+		})
+	}
+
+	p, _ := parsePeriod(jakarta, models.PeriodQuery{StartDate: "2026-10-14", EndDate: "2026-10-14"}, MaxReportRangeDays)
+	if _, err := comparePeriod(p, "minggu_lalu"); err == nil {
+		t.Error("comparePeriod() mode tidak dikenal seharusnya error")
+	}
+}
@@ -1,22 +1,579 @@
 package services
 
 import (
+	"fmt"
+	"kasir-api/apperrors"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
 )
 
 type ReportService struct {
 	repo *repositories.ReportRepository
+	loc  *time.Location // zona waktu toko, batas hari & pengelompokan laporan mengikuti ini
 }
 
-func NewReportService(repo *repositories.ReportRepository) *ReportService {
-	return &ReportService{repo: repo}
+func NewReportService(repo *repositories.ReportRepository, loc *time.Location) *ReportService {
+	return &ReportService{repo: repo, loc: loc}
 }
 
-func (s *ReportService) GetDailyReport() (*models.Report, error) {
-	return s.repo.GetDailyReport()
+// batas jumlah produk per peringkat laporan
+const (
+	DefaultReportTopN = 5
+	MaxReportTopN     = 100
+)
+
+func validateTopN(topN int) (int, error) {
+	if topN == 0 {
+		return DefaultReportTopN, nil
+	}
+	if topN < 0 || topN > MaxReportTopN {
+		return 0, apperrors.Validation(apperrors.CodeInvalid, "top", fmt.Sprintf("top harus antara 1 dan %d", MaxReportTopN))
+	}
+	return topN, nil
+}
+
+// GetDailyReport laporan hari ini menurut zona waktu toko
+func (s *ReportService) GetDailyReport(outletID, topN int) (*models.Report, error) {
+	return s.GetReportByDateRange(models.PeriodQuery{Range: RangeToday}, "", outletID, topN)
+}
+
+// GetReportByDateRange laporan periode q, compare (previous / last_year) opsional untuk menambah periode pembanding
+func (s *ReportService) GetReportByDateRange(q models.PeriodQuery, compare string, outletID, topN int) (*models.Report, error) {
+	topN, err := validateTopN(topN)
+	if err != nil {
+		return nil, err
+	}
+	period, err := parsePeriod(s.loc, q, MaxReportRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	compare, previous, err := parseCompare(period, compare)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.repo.GetReportByDateRange(period.Start, period.End, outletID, topN)
+	if err != nil {
+		return nil, err
+	}
+	report.StartDate, report.EndDate = period.StartDate, period.EndDate
+
+	if compare != "" {
+		revenue, count, err := s.repo.GetTotals(previous.Start, previous.End, outletID)
+		if err != nil {
+			return nil, err
+		}
+		report.Comparison = &models.Comparison{
+			Mode:             compare,
+			StartDate:        previous.StartDate,
+			EndDate:          previous.EndDate,
+			TotalRevenue:     metricDelta(report.TotalRevenue, revenue),
+			TotalTransaction: metricDelta(report.TotalTransaction, count),
+			AverageBasket:    metricDelta(averageBasket(report.TotalRevenue, report.TotalTransaction), averageBasket(revenue, count)),
+		}
+	}
+	return report, nil
+}
+
+// parseCompare mode pembanding yang sudah dinormalisasi beserta periodenya, mode kosong berarti tanpa pembanding
+func parseCompare(period reportPeriod, compare string) (string, reportPeriod, error) {
+	compare = strings.ToLower(strings.TrimSpace(compare))
+	if compare == "" {
+		return "", reportPeriod{}, nil
+	}
+	previous, err := comparePeriod(period, compare)
+	if err != nil {
+		return "", reportPeriod{}, err
+	}
+	return compare, previous, nil
+}
+
+func averageBasket(revenue, count int) int {
+	if count == 0 {
+		return 0
+	}
+	return revenue / count
+}
+
+// metricDelta selisih current terhadap previous, persen dibulatkan 2 angka desimal
+func metricDelta(current, previous int) models.MetricDelta {
+	delta := models.MetricDelta{Current: current, Previous: previous, Change: current - previous}
+	if previous != 0 {
+		pct := math.Round(float64(delta.Change)*10000/float64(previous)) / 100
+		delta.ChangePct = &pct
+	}
+	return delta
+}
+
+// GetCategoryRollup penjualan per kategori (termasuk sub kategori) di bawah parentID,
+// compare (previous / last_year) opsional menambah selisih tiap kategori terhadap periode pembanding
+func (s *ReportService) GetCategoryRollup(q models.PeriodQuery, compare string, parentID, outletID int) ([]models.CategoryRollup, error) {
+	period, err := parsePeriod(s.loc, q, MaxReportRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	compare, previous, err := parseCompare(period, compare)
+	if err != nil {
+		return nil, err
+	}
+
+	rollup, err := s.repo.GetCategoryRollup(period.Start, period.End, parentID, outletID)
+	if err != nil {
+		return nil, err
+	}
+	if compare == "" {
+		return rollup, nil
+	}
+
+	before, err := s.repo.GetCategoryRollup(previous.Start, previous.End, parentID, outletID)
+	if err != nil {
+		return nil, err
+	}
+	prev := make(map[int]models.CategoryRollup, len(before))
+	for _, c := range before {
+		prev[c.CategoryID] = c
+	}
+	for i, c := range rollup {
+		p := prev[c.CategoryID]
+		rollup[i].Comparison = &models.RowComparison{
+			QtyTerjual: metricDelta(c.QtyTerjual, p.QtyTerjual),
+			Revenue:    metricDelta(c.TotalRevenue, p.TotalRevenue),
+		}
+	}
+	return rollup, nil
+}
+
+// batas rentang tanggal per group_by supaya jumlah periode tetap wajar untuk grafik
+var salesSeriesMaxDays = map[string]int{
+	"hour":  31,
+	"day":   366,
+	"week":  366 * 3,
+	"month": 366 * 10,
+}
+
+// GetSalesSeries tren penjualan per jam / hari / minggu / bulan, default per hari.
+// compare (previous / last_year) opsional menambah tren periode pembanding dengan group_by yang sama
+func (s *ReportService) GetSalesSeries(q models.PeriodQuery, groupBy, compare string, outletID int) (*models.SalesSeries, error) {
+	groupBy = strings.ToLower(strings.TrimSpace(groupBy))
+	if groupBy == "" {
+		groupBy = "day"
+	}
+	maxDays, ok := salesSeriesMaxDays[groupBy]
+	if !ok {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "group_by", "group_by harus hour, day, week atau month")
+	}
+
+	period, err := parsePeriod(s.loc, q, maxDays)
+	if err != nil {
+		return nil, err
+	}
+
+	compare, previous, err := parseCompare(period, compare)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, err := s.salesBuckets(period, groupBy, outletID)
+	if err != nil {
+		return nil, err
+	}
+	series := models.SalesSeries{GroupBy: groupBy, StartDate: period.StartDate, EndDate: period.EndDate, Buckets: buckets}
+	if compare == "" {
+		return &series, nil
+	}
+
+	before, err := s.salesBuckets(previous, groupBy, outletID)
+	if err != nil {
+		return nil, err
+	}
+	current, prev := sumBuckets(buckets), sumBuckets(before)
+	series.Comparison = &models.SeriesComparison{
+		Comparison: models.Comparison{
+			Mode:             compare,
+			StartDate:        previous.StartDate,
+			EndDate:          previous.EndDate,
+			TotalRevenue:     metricDelta(current.Revenue, prev.Revenue),
+			TotalTransaction: metricDelta(current.TransactionCount, prev.TransactionCount),
+			AverageBasket:    metricDelta(current.AverageBasket, prev.AverageBasket),
+		},
+		ItemsSold: metricDelta(current.ItemsSold, prev.ItemsSold),
+		Buckets:   before,
+	}
+	return &series, nil
+}
+
+func (s *ReportService) salesBuckets(period reportPeriod, groupBy string, outletID int) ([]models.SalesBucket, error) {
+	buckets, err := s.repo.GetSalesSeries(period.Start, period.End, groupBy, outletID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range buckets {
+		buckets[i].Period = buckets[i].Period.In(s.loc)
+	}
+	return buckets, nil
 }
 
-func (s *ReportService) GetReportByDateRange(startDate, endDate string) (*models.Report, error) {
-	return s.repo.GetReportByDateRange(startDate, endDate)
+// sumBuckets total semua periode tren, Period tidak dipakai
+func sumBuckets(buckets []models.SalesBucket) models.SalesBucket {
+	var total models.SalesBucket
+	for _, b := range buckets {
+		total.Revenue += b.Revenue
+		total.TransactionCount += b.TransactionCount
+		total.ItemsSold += b.ItemsSold
+	}
+	total.AverageBasket = averageBasket(total.Revenue, total.TransactionCount)
+	return total
+}
+
+// batas baris per halaman breakdown
+const (
+	DefaultBreakdownPageSize = 20
+	MaxBreakdownPageSize     = 100
+)
+
+// GetSalesBreakdown breakdown penjualan per kategori (default) atau per produk.
+// Default urut revenue terbesar, sort=name default A-Z
+func (s *ReportService) GetSalesBreakdown(q models.BreakdownQuery) (*models.SalesBreakdown, error) {
+	q.By = strings.ToLower(strings.TrimSpace(q.By))
+	if q.By == "" {
+		q.By = models.BreakdownByCategory
+	}
+	if q.By != models.BreakdownByCategory && q.By != models.BreakdownByProduct {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "by", "by harus category atau product")
+	}
+
+	q.Sort = strings.ToLower(strings.TrimSpace(q.Sort))
+	switch q.Sort {
+	case "":
+		q.Sort = "revenue"
+	case "revenue", "qty", "name":
+	default:
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "sort", "sort harus revenue, qty atau name")
+	}
+
+	q.Order = strings.ToLower(strings.TrimSpace(q.Order))
+	switch q.Order {
+	case "":
+		q.Order = "desc"
+		if q.Sort == "name" {
+			q.Order = "asc"
+		}
+	case "asc", "desc":
+	default:
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "order", "order harus asc atau desc")
+	}
+
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.Page < 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "page", "page minimal 1")
+	}
+	if q.PageSize == 0 {
+		q.PageSize = DefaultBreakdownPageSize
+	}
+	if q.PageSize < 0 || q.PageSize > MaxBreakdownPageSize {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "page_size", fmt.Sprintf("page_size harus antara 1 dan %d", MaxBreakdownPageSize))
+	}
+
+	period, err := parsePeriod(s.loc, q.Period, MaxReportRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	compare, previous, err := parseCompare(period, q.Compare)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown, err := s.repo.GetSalesBreakdown(q, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.StartDate, breakdown.EndDate = period.StartDate, period.EndDate
+	if compare == "" {
+		return breakdown, nil
+	}
+
+	// semua baris periode pembanding supaya baris di halaman ini tetap ketemu pasangannya
+	all := q
+	all.All = true
+	before, err := s.repo.GetSalesBreakdown(all, previous.Start, previous.End)
+	if err != nil {
+		return nil, err
+	}
+	prev := make(map[int]models.BreakdownRow, len(before.Rows))
+	for _, b := range before.Rows {
+		prev[b.ID] = b
+	}
+	for i, b := range breakdown.Rows {
+		p := prev[b.ID]
+		breakdown.Rows[i].Comparison = &models.RowComparison{
+			QtyTerjual: metricDelta(b.QtyTerjual, p.QtyTerjual),
+			Revenue:    metricDelta(b.Revenue, p.Revenue),
+		}
+	}
+	breakdown.Comparison = &models.BreakdownComparison{
+		Mode:         compare,
+		StartDate:    previous.StartDate,
+		EndDate:      previous.EndDate,
+		TotalQty:     metricDelta(breakdown.TotalQty, before.TotalQty),
+		TotalRevenue: metricDelta(breakdown.TotalRevenue, before.TotalRevenue),
+	}
+	return breakdown, nil
+}
+
+// jendela penjualan untuk kecepatan jual di laporan persediaan
+const (
+	DefaultInventoryDays = 30
+	MaxInventoryDays     = 365
+)
+
+// GetInventory laporan persediaan per produk (default) atau per kategori: stok, nilai stok pada harga jual
+// dan harga pokok, kecepatan jual rata-rata per hari dalam jendela days terakhir (termasuk hari ini),
+// perkiraan hari sampai stok habis dan umur sejak penjualan terakhir. Default urut nilai stok terbesar
+func (s *ReportService) GetInventory(q models.InventoryQuery) (*models.InventoryReport, error) {
+	q.By = strings.ToLower(strings.TrimSpace(q.By))
+	if q.By == "" {
+		q.By = models.InventoryByProduct
+	}
+	if q.By != models.InventoryByProduct && q.By != models.InventoryByCategory {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "by", "by harus product atau category")
+	}
+
+	q.Sort = strings.ToLower(strings.TrimSpace(q.Sort))
+	switch q.Sort {
+	case "":
+		q.Sort = "value"
+	case "value", "stock", "cover", "velocity", "aging", "name":
+	default:
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "sort", "sort harus value, stock, cover, velocity, aging atau name")
+	}
+
+	q.Order = strings.ToLower(strings.TrimSpace(q.Order))
+	switch q.Order {
+	case "":
+		q.Order = "desc"
+		if q.Sort == "name" || q.Sort == "cover" {
+			q.Order = "asc"
+		}
+	case "asc", "desc":
+	default:
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "order", "order harus asc atau desc")
+	}
+
+	if q.Days == 0 {
+		q.Days = DefaultInventoryDays
+	}
+	if q.Days < 0 || q.Days > MaxInventoryDays {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "days", fmt.Sprintf("days harus antara 1 dan %d", MaxInventoryDays))
+	}
+
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.Page < 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "page", "page minimal 1")
+	}
+	if q.PageSize == 0 {
+		q.PageSize = DefaultBreakdownPageSize
+	}
+	if q.PageSize < 0 || q.PageSize > MaxBreakdownPageSize {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "page_size", fmt.Sprintf("page_size harus antara 1 dan %d", MaxBreakdownPageSize))
+	}
+
+	now := time.Now().In(s.loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	start := today.AddDate(0, 0, -(q.Days - 1))
+	end := today.AddDate(0, 0, 1)
+
+	items, err := s.repo.GetInventory(start, end, q.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.InventoryReport{
+		By:          q.By,
+		Days:        q.Days,
+		WindowStart: start.Format("2006-01-02"),
+		WindowEnd:   today.Format("2006-01-02"),
+		Sort:        q.Sort,
+		Order:       q.Order,
+		Page:        q.Page,
+		PageSize:    q.PageSize,
+	}
+
+	rows := make([]models.InventoryRow, 0, len(items))
+	categories := make(map[int]int) // id kategori -> index di rows
+	for _, item := range items {
+		item.RetailValue = item.Stock * item.Price
+		if item.Cost != nil {
+			value := item.Stock * *item.Cost
+			item.CostValue = &value
+			report.TotalCostValue += value
+		} else {
+			item.CostUnknownProducts = 1
+		}
+		report.TotalStock += item.Stock
+		report.TotalRetailValue += item.RetailValue
+		report.CostUnknownProducts += item.CostUnknownProducts
+
+		if q.By == models.InventoryByProduct {
+			rows = append(rows, item)
+			continue
+		}
+
+		idx, ok := categories[item.CategoryID]
+		if !ok {
+			idx = len(rows)
+			categories[item.CategoryID] = idx
+			name := item.CategoryName
+			if item.CategoryID == 0 {
+				name = "Tanpa Kategori"
+			}
+			rows = append(rows, models.InventoryRow{ID: item.CategoryID, Nama: name})
+		}
+		c := &rows[idx]
+		c.ProductCount++
+		c.Stock += item.Stock
+		c.RetailValue += item.RetailValue
+		if item.CostValue != nil {
+			if c.CostValue == nil {
+				c.CostValue = new(int)
+			}
+			*c.CostValue += *item.CostValue
+		}
+		c.CostUnknownProducts += item.CostUnknownProducts
+		c.QtyTerjual += item.QtyTerjual
+		// format YYYY-MM-DD, urutan string sama dengan urutan tanggal
+		if item.LastSoldDate != nil && (c.LastSoldDate == nil || *item.LastSoldDate > *c.LastSoldDate) {
+			c.LastSoldDate = item.LastSoldDate
+		}
+	}
+
+	for i := range rows {
+		r := &rows[i]
+		r.DailyVelocity = math.Round(float64(r.QtyTerjual)*100/float64(q.Days)) / 100
+		if r.QtyTerjual > 0 {
+			cover := math.Round(float64(r.Stock)*float64(q.Days)*10/float64(r.QtyTerjual)) / 10
+			r.DaysOfCover = &cover
+		}
+		if r.LastSoldDate != nil {
+			soldDay, err := time.ParseInLocation("2006-01-02", *r.LastSoldDate, s.loc)
+			if err != nil {
+				return nil, err
+			}
+			// dibulatkan karena hari bisa 23 atau 25 jam di zona waktu yang punya DST
+			age := int(math.Round(today.Sub(soldDay).Hours() / 24))
+			r.DaysSinceLastSale = &age
+		}
+	}
+
+	sortInventory(rows, q.Sort, q.Order == "desc")
+	report.TotalRows = len(rows)
+	if !q.All {
+		from := (q.Page - 1) * q.PageSize
+		if from > len(rows) {
+			from = len(rows)
+		}
+		to := from + q.PageSize
+		if to > len(rows) {
+			to = len(rows)
+		}
+		rows = rows[from:to]
+	}
+	report.Rows = rows
+	return &report, nil
+}
+
+// sortInventory urutkan baris persediaan. Tanpa penjualan berarti cover dan aging tak terhingga,
+// jadi paling akhir di urutan naik dan paling awal di urutan turun. Seri diurutkan nama lalu id
+func sortInventory(rows []models.InventoryRow, sortBy string, desc bool) {
+	key := func(r models.InventoryRow) float64 {
+		switch sortBy {
+		case "stock":
+			return float64(r.Stock)
+		case "cover":
+			if r.DaysOfCover == nil {
+				return math.Inf(1)
+			}
+			return *r.DaysOfCover
+		case "velocity":
+			return r.DailyVelocity
+		case "aging":
+			if r.DaysSinceLastSale == nil {
+				return math.Inf(1)
+			}
+			return float64(*r.DaysSinceLastSale)
+		case "name":
+			return 0
+		}
+		return float64(r.RetailValue)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := key(rows[i]), key(rows[j])
+		if a != b {
+			if desc {
+				return a > b
+			}
+			return a < b
+		}
+		if rows[i].Nama != rows[j].Nama {
+			if sortBy == "name" && desc {
+				return rows[i].Nama > rows[j].Nama
+			}
+			return rows[i].Nama < rows[j].Nama
+		}
+		return rows[i].ID < rows[j].ID
+	})
+}
+
+// RebuildDailySummary hitung ulang ringkasan penjualan harian untuk startDate s/d endDate (YYYY-MM-DD).
+// Keduanya kosong berarti dari transaksi pertama sampai hari ini. Mengembalikan rentang yang dihitung
+// dan jumlah transaksinya
+func (s *ReportService) RebuildDailySummary(startDate, endDate string) (string, string, int, error) {
+	if startDate == "" && endDate == "" {
+		first, err := s.repo.GetFirstSaleAt()
+		if err != nil {
+			return "", "", 0, err
+		}
+		if first == nil {
+			return "", "", 0, nil
+		}
+		startDate = first.In(s.loc).Format("2006-01-02")
+		endDate = time.Now().In(s.loc).Format("2006-01-02")
+	}
+
+	period, err := parsePeriod(s.loc, models.PeriodQuery{StartDate: startDate, EndDate: endDate}, math.MaxInt32)
+	if err != nil {
+		return "", "", 0, err
+	}
+	count, err := s.repo.RebuildDailySummary(period.Start, period.End)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return period.StartDate, period.EndDate, count, nil
+}
+
+// EnsureDailySummary isi ringkasan harian dari semua transaksi kalau masih kosong,
+// misalnya pertama kali jalan setelah tabel ringkasan ditambahkan
+func (s *ReportService) EnsureDailySummary() error {
+	empty, err := s.repo.IsDailySummaryEmpty()
+	if err != nil || !empty {
+		return err
+	}
+
+	startDate, endDate, count, err := s.RebuildDailySummary("", "")
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("Ringkasan harian diisi dari %d transaksi (%s s/d %s)\n", count, startDate, endDate)
+	}
+	return nil
 }
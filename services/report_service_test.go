@@ -0,0 +1,35 @@
+package services
+
+import (
+	"kasir-api/models"
+	"testing"
+)
+
+func TestMetricDelta(t *testing.T) {
+	d := metricDelta(150, 120)
+	if d.Change != 30 || d.ChangePct == nil || *d.ChangePct != 25 {
+		t.Errorf("metricDelta(150, 120) = %+v", d)
+	}
+	d = metricDelta(2, 3)
+	if d.Change != -1 || d.ChangePct == nil || *d.ChangePct != -33.33 {
+		t.Errorf("metricDelta(2, 3) = %+v", d)
+	}
+	if d := metricDelta(10, 0); d.ChangePct != nil {
+		t.Errorf("metricDelta(10, 0) ChangePct = %v, want nil", *d.ChangePct)
+	}
+}
+
+func TestSumBuckets(t *testing.T) {
+	total := sumBuckets([]models.SalesBucket{
+		{Revenue: 10000, TransactionCount: 2, ItemsSold: 5, AverageBasket: 5000},
+		{},
+		{Revenue: 5000, TransactionCount: 1, ItemsSold: 1, AverageBasket: 5000},
+	})
+	want := models.SalesBucket{Revenue: 15000, TransactionCount: 3, ItemsSold: 6, AverageBasket: 5000}
+	if total != want {
+		t.Errorf("sumBuckets() = %+v, want %+v", total, want)
+	}
+	if total := sumBuckets(nil); total != (models.SalesBucket{}) {
+		t.Errorf("sumBuckets(nil) = %+v", total)
+	}
+}
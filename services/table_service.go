@@ -0,0 +1,59 @@
+package services
+
+import (
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type TableService struct {
+	repo *repositories.TableRepository
+}
+
+func NewTableService(repo *repositories.TableRepository) *TableService {
+	return &TableService{repo: repo}
+}
+
+func validateTable(t *models.DiningTable) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "name", "Nama / nomor meja wajib diisi")
+	}
+	if t.Seats < 0 {
+		return apperrors.Validation(apperrors.CodeInvalid, "seats", "Jumlah kursi tidak boleh negatif")
+	}
+	return nil
+}
+
+func (s *TableService) GetAll(includeArchived bool) ([]models.DiningTable, error) {
+	return s.repo.GetAll(includeArchived)
+}
+
+func (s *TableService) GetByID(id int) (*models.DiningTable, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TableService) Create(table *models.DiningTable) error {
+	err := validateTable(table)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Create(table))
+}
+
+func (s *TableService) Update(table *models.DiningTable) error {
+	err := validateTable(table)
+	if err != nil {
+		return err
+	}
+	return translateDBError(s.repo.Update(table))
+}
+
+func (s *TableService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *TableService) Restore(id int) error {
+	return s.repo.Restore(id)
+}
@@ -1,18 +1,59 @@
 package services
 
 import (
+	"kasir-api/apperrors"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"strings"
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo    *repositories.TransactionRepository
+	loyalty models.LoyaltyConfig
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo *repositories.TransactionRepository, loyalty models.LoyaltyConfig) *TransactionService {
+	return &TransactionService{repo: repo, loyalty: loyalty}
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem, useLock bool) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(items)
+func (s *TransactionService) Checkout(req models.CheckoutRequest) (*models.Transaction, error) {
+	if len(req.Items) == 0 {
+		return nil, apperrors.Validation(apperrors.CodeRequired, "items", "Items wajib diisi")
+	}
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, apperrors.Validation(apperrors.CodeInvalid, "quantity", "Quantity harus lebih dari 0")
+		}
+	}
+	if req.RedeemPoints < 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "redeem_points", "Poin tidak boleh negatif")
+	}
+	if req.RedeemPoints > 0 && req.CustomerID == 0 {
+		return nil, apperrors.Validation(apperrors.CodeRequired, "customer_id", "Customer wajib diisi untuk tukar poin")
+	}
+	req.PriceList = strings.ToLower(strings.TrimSpace(req.PriceList))
+	method, err := validatePaymentMethod(req.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	req.PaymentMethod = method
+	transaction, err := s.repo.CreateTransaction(req, s.loyalty)
+	if err != nil {
+		return nil, translateDBError(err)
+	}
+	return transaction, nil
+}
+
+// validatePaymentMethod metode pembayaran harus salah satu models.PaymentMethods, kosong berarti cash
+func validatePaymentMethod(method string) (string, error) {
+	method = strings.ToLower(strings.TrimSpace(method))
+	if method == "" {
+		return models.PaymentCash, nil
+	}
+	for _, m := range models.PaymentMethods {
+		if method == m {
+			return method, nil
+		}
+	}
+	return "", apperrors.Validation(apperrors.CodeInvalid, "payment_method", "payment_method harus "+strings.Join(models.PaymentMethods, ", "))
 }
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type TransferService struct {
+	repo *repositories.TransferRepository
+}
+
+func NewTransferService(repo *repositories.TransferRepository) *TransferService {
+	return &TransferService{repo: repo}
+}
+
+func validateTransfer(req *models.StockTransferRequest) error {
+	req.Note = strings.TrimSpace(req.Note)
+	if req.FromOutletID <= 0 {
+		return apperrors.Validation(apperrors.CodeRequired, "from_outlet_id", "Outlet asal wajib diisi")
+	}
+	if req.ToOutletID <= 0 {
+		return apperrors.Validation(apperrors.CodeRequired, "to_outlet_id", "Outlet tujuan wajib diisi")
+	}
+	if req.FromOutletID == req.ToOutletID {
+		return apperrors.Validation(apperrors.CodeInvalid, "to_outlet_id", "Outlet tujuan harus berbeda dengan outlet asal")
+	}
+	if len(req.Items) == 0 {
+		return apperrors.Validation(apperrors.CodeRequired, "items", "Produk wajib diisi")
+	}
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return apperrors.Validation(apperrors.CodeInvalid, "quantity", "Quantity harus lebih dari 0")
+		}
+	}
+	return nil
+}
+
+func (s *TransferService) GetAll(status string, outletID int) ([]models.StockTransfer, error) {
+	return s.repo.GetAll(status, outletID)
+}
+
+func (s *TransferService) GetByID(id int) (*models.StockTransfer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TransferService) Create(req models.StockTransferRequest) (*models.StockTransfer, error) {
+	err := validateTransfer(&req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Create(req)
+}
+
+func (s *TransferService) Update(id int, req models.StockTransferRequest) (*models.StockTransfer, error) {
+	err := validateTransfer(&req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Update(id, req)
+}
+
+func (s *TransferService) Ship(id int) (*models.StockTransfer, error) {
+	return s.repo.Ship(id)
+}
+
+func (s *TransferService) Receive(id int, req models.ReceiveTransferRequest) (*models.StockTransfer, error) {
+	req.Note = strings.TrimSpace(req.Note)
+	for _, item := range req.Items {
+		if item.ReceivedQuantity < 0 {
+			return nil, apperrors.Validation(apperrors.CodeInvalid, "received_quantity", "Qty diterima tidak boleh negatif")
+		}
+	}
+	return s.repo.Receive(id, req)
+}
+
+func (s *TransferService) Cancel(id int) (*models.StockTransfer, error) {
+	return s.repo.Cancel(id)
+}
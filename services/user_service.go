@@ -0,0 +1,75 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type UserService struct {
+	repo *repositories.UserRepository
+}
+
+func NewUserService(repo *repositories.UserRepository) *UserService {
+	return &UserService{repo: repo}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *UserService) GetAll() ([]models.User, error) {
+	return s.repo.GetAll()
+}
+
+// Create buat user baru beserta token API-nya, token hanya dikembalikan sekali ini
+func (s *UserService) Create(user *models.User) error {
+	user.Username = strings.ToLower(strings.TrimSpace(user.Username))
+	user.Name = strings.TrimSpace(user.Name)
+	user.Role = strings.ToLower(strings.TrimSpace(user.Role))
+	if user.Username == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "username", "Username wajib diisi")
+	}
+	if user.Role == "" {
+		user.Role = models.UserRoleCashier
+	}
+	if user.Role != models.UserRoleAdmin && user.Role != models.UserRoleCashier {
+		return apperrors.Validation(apperrors.CodeInvalid, "role", "Role harus admin atau cashier")
+	}
+	if user.OutletID != nil && *user.OutletID <= 0 {
+		user.OutletID = nil
+	}
+
+	raw := make([]byte, 24)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return err
+	}
+	token := hex.EncodeToString(raw)
+
+	err = translateDBError(s.repo.Create(user, hashToken(token)))
+	if err != nil {
+		return err
+	}
+	user.Token = token
+	return nil
+}
+
+func (s *UserService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+// HasUsers true kalau sudah ada user, sejak itu semua request wajib pakai token
+func (s *UserService) HasUsers() (bool, error) {
+	return s.repo.Exists()
+}
+
+// Authenticate cari user dari token Bearer
+func (s *UserService) Authenticate(token string) (*models.User, error) {
+	return s.repo.GetByTokenHash(hashToken(token))
+}
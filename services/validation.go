@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+func validateProduct(p *models.Product) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "name", "Nama produk wajib diisi")
+	}
+	if p.Price < 0 {
+		return apperrors.Validation(apperrors.CodeInvalid, "price", "Harga tidak boleh negatif")
+	}
+	if p.Stock < 0 {
+		return apperrors.Validation(apperrors.CodeInvalid, "stock", "Stok tidak boleh negatif")
+	}
+	if p.Cost != nil && *p.Cost < 0 {
+		return apperrors.Validation(apperrors.CodeInvalid, "cost", "Harga pokok tidak boleh negatif")
+	}
+	if p.CategoryID < 0 {
+		return apperrors.Validation(apperrors.CodeInvalid, "category_id", "Category ID tidak valid")
+	}
+	return nil
+}
+
+func validateCategory(c *models.Category) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "name", "Nama kategori wajib diisi")
+	}
+	return nil
+}
+
+func validateCustomer(c *models.Customer) error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Phone = strings.TrimSpace(c.Phone)
+	c.Email = strings.ToLower(strings.TrimSpace(c.Email))
+	c.PriceList = strings.ToLower(strings.TrimSpace(c.PriceList))
+
+	if c.Name == "" {
+		return apperrors.Validation(apperrors.CodeRequired, "name", "Nama customer wajib diisi")
+	}
+	if c.Email != "" && !strings.Contains(c.Email, "@") {
+		return apperrors.Validation(apperrors.CodeInvalid, "email", "Format email tidak valid")
+	}
+	return nil
+}
+
+// translateDBError ubah error constraint PostgreSQL jadi error domain,
+// error database lain dikembalikan apa adanya (handler membalas 500 tanpa pesan driver)
+func translateDBError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		conflict := apperrors.Conflict("Data sudah ada")
+		conflict.Field = pqErr.Column
+		return conflict
+	case "foreign_key_violation":
+		return apperrors.Validation(apperrors.CodeInvalidReference, pqErr.Column, "Data yang direferensikan tidak ditemukan atau masih dipakai")
+	case "not_null_violation":
+		return apperrors.Validation(apperrors.CodeRequired, pqErr.Column, fmt.Sprintf("Field %s wajib diisi", pqErr.Column))
+	case "check_violation":
+		return apperrors.Validation(apperrors.CodeInvalid, pqErr.Column, "Data tidak valid")
+	case "object_not_in_prerequisite_state":
+		// trigger hari bisnis yang sudah ditutup Z report, pesannya dari database
+		return apperrors.Conflict(pqErr.Message)
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/apperrors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestTranslateDBErrorClosedDay(t *testing.T) {
+	// kode error trigger closed_day_guard dan z_reports_immutable
+	err := translateDBError(&pq.Error{Code: "55000", Message: "transaksi 7 sudah masuk Z report dan tidak bisa diubah"})
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.ErrConflict {
+		t.Fatalf("err = %v, want conflict", err)
+	}
+	if appErr.Message != "transaksi 7 sudah masuk Z report dan tidak bisa diubah" {
+		t.Errorf("Message = %q", appErr.Message)
+	}
+}
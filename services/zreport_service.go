@@ -0,0 +1,74 @@
+package services
+
+import (
+	"kasir-api/apperrors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+	"time"
+)
+
+type ZReportService struct {
+	repo *repositories.ZReportRepository
+	loc  *time.Location // zona waktu toko, menentukan batas hari bisnis
+}
+
+func NewZReportService(repo *repositories.ZReportRepository, loc *time.Location) *ZReportService {
+	return &ZReportService{repo: repo, loc: loc}
+}
+
+// GetAll Z report dengan tanggal bisnis dalam periode q (default hari ini)
+func (s *ZReportService) GetAll(outletID int, q models.PeriodQuery) ([]models.ZReport, error) {
+	period, err := parsePeriod(s.loc, q, MaxReportRangeDays)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := s.repo.GetAll(outletID, period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reports {
+		s.localize(&reports[i])
+	}
+	return reports, nil
+}
+
+func (s *ZReportService) GetByID(id int) (*models.ZReport, error) {
+	report, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.localize(report)
+	return report, nil
+}
+
+// localize tampilkan waktu Z report di zona waktu toko
+func (s *ZReportService) localize(z *models.ZReport) {
+	z.PeriodStart = z.PeriodStart.In(s.loc)
+	z.PeriodEnd = z.PeriodEnd.In(s.loc)
+	z.ClosedAt = z.ClosedAt.In(s.loc)
+}
+
+// Close tutup hari bisnis, default hari ini. Hari yang belum dimulai tidak bisa ditutup
+func (s *ZReportService) Close(req models.ZReportRequest, closedBy *int) (*models.ZReport, error) {
+	req.Note = strings.TrimSpace(req.Note)
+	req.BusinessDate = strings.TrimSpace(req.BusinessDate)
+	if req.BusinessDate == "" {
+		req.BusinessDate = time.Now().In(s.loc).Format("2006-01-02")
+	}
+
+	start, err := time.ParseInLocation("2006-01-02", req.BusinessDate, s.loc)
+	if err != nil {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "business_date", "business_date harus tanggal valid dengan format YYYY-MM-DD")
+	}
+	if start.After(time.Now()) {
+		return nil, apperrors.Validation(apperrors.CodeInvalid, "business_date", "Hari bisnis yang belum dimulai tidak bisa ditutup")
+	}
+
+	report, err := s.repo.Close(req, start, start.AddDate(0, 0, 1), closedBy)
+	if err != nil {
+		return nil, translateDBError(err)
+	}
+	s.localize(report)
+	return report, nil
+}